@@ -0,0 +1,207 @@
+package tmpl
+
+import (
+	"cmp"
+	"html/template"
+	"slices"
+	"strings"
+	"text/template/parse"
+)
+
+// Reference is a reference to a template by name from another template.
+type Reference struct {
+	// Name is the referenced template name.
+	Name string
+
+	// Kind is the kind of reference, one of "template", "tmpl", "stream", "render", "component" or "url".
+	// The name of a component reference is a registered component name and the name of a url reference is a route name.
+	Kind string
+
+	// From is the name of the template that contains the reference.
+	From string
+
+	// Location is the file template name, line and column of the reference, ie. "pages/index:3:12".
+	Location string
+}
+
+// Analysis reports problems found by a static analysis of the parsed templates.
+type Analysis struct {
+	// Missing are references to templates that are not defined and url references to routes that are not in the route table.
+	Missing []Reference
+
+	// Unused are the names of autoloaded templates that are not referenced by any other template.
+	Unused []string
+
+	// Orphans are the names of :pending and :error templates whose base template is not defined.
+	Orphans []string
+}
+
+// Analyze walks the parse tree of every template for references to other templates.
+//
+// References are {{ template "name" }} actions and tmpl, stream and render calls with a constant template name,
+// including {{ #render "name" }} blocks, and component calls with a constant component name.
+// A component reference is a reference to the template of the registered component.
+// A reference is missing if the referenced template is not defined in any template that contains the reference.
+// url calls with a constant route name are missing if the route is not in the route table.
+//
+// Templates that are only rendered from Go code are not referenced and are reported as unused if autoloaded.
+// Lazy templates are parsed first and skipped if they fail to parse.
+func (t Templates) Analyze() Analysis {
+	var analysis Analysis
+	type refKey struct{ name, kind, from, location string }
+	found := make(map[refKey]bool)
+	var refs []Reference
+	usedFiles := make(map[string]bool)
+	orphans := make(map[string]bool)
+
+	for _, name := range t.names() {
+		tmpl, err := t.lookup(name)
+		if err != nil || tmpl == nil {
+			continue
+		}
+		for _, assoc := range tmpl.Templates() {
+			if assoc.Tree == nil || assoc.Tree.Root == nil {
+				continue
+			}
+			if base, ok := variantBase(assoc.Name()); ok && tmpl.Lookup(base) == nil {
+				orphans[assoc.Name()] = true
+			}
+			for _, ref := range templateReferences(assoc.Name(), assoc.Tree) {
+				key := refKey{ref.Name, ref.Kind, ref.From, ref.Location}
+				if _, seen := found[key]; !seen {
+					refs = append(refs, ref)
+					found[key] = false
+				}
+				target := t.referenced(tmpl, ref)
+				if target == nil {
+					continue
+				}
+				found[key] = true
+				if target.Tree != nil && target.Tree.ParseName != assoc.Tree.ParseName {
+					usedFiles[target.Tree.ParseName] = true
+				}
+			}
+			for _, ref := range urlReferences(assoc.Name(), assoc.Tree) {
+				key := refKey{ref.Name, ref.Kind, ref.From, ref.Location}
+				if _, seen := found[key]; !seen {
+					refs = append(refs, ref)
+				}
+				_, found[key] = t.urls.lookup(ref.Name)
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		if !found[refKey{ref.Name, ref.Kind, ref.From, ref.Location}] {
+			analysis.Missing = append(analysis.Missing, ref)
+		}
+	}
+	slices.SortFunc(analysis.Missing, func(a, b Reference) int {
+		return cmp.Or(strings.Compare(a.Location, b.Location), strings.Compare(a.Name, b.Name))
+	})
+	for name, meta := range t.metadata {
+		if meta.Autoloaded && !usedFiles[name] {
+			analysis.Unused = append(analysis.Unused, name)
+		}
+	}
+	slices.Sort(analysis.Unused)
+	for name := range orphans {
+		analysis.Orphans = append(analysis.Orphans, name)
+	}
+	slices.Sort(analysis.Orphans)
+	return analysis
+}
+
+// variantBase returns the base template name of a :pending or :error template.
+func variantBase(name string) (string, bool) {
+	for _, suffix := range []string{":pending", ":error"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok && base != "" {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// templateReferences returns the references to other templates in the parse tree of the template name.
+func templateReferences(name string, tree *parse.Tree) []Reference {
+	var refs []Reference
+	add := func(node parse.Node, ref, kind string) {
+		location, _ := tree.ErrorContext(node)
+		refs = append(refs, Reference{Name: ref, Kind: kind, From: name, Location: location})
+	}
+	walkTree(tree.Root, func(node parse.Node) {
+		switch node := node.(type) {
+		case *parse.TemplateNode:
+			add(node, node.Name, "template")
+		case *parse.CommandNode:
+			if len(node.Args) < 2 {
+				return
+			}
+			ident, ok := node.Args[0].(*parse.IdentifierNode)
+			if !ok || !slices.Contains([]string{"tmpl", "stream", "render", "component"}, ident.Ident) {
+				return
+			}
+			if str, ok := node.Args[1].(*parse.StringNode); ok {
+				add(node, str.Text, ident.Ident)
+			}
+		}
+	})
+	return refs
+}
+
+// referenced returns the template referenced by ref in tmpl or nil if it is not defined,
+// component references resolve to the template of the registered component.
+func (t Templates) referenced(tmpl *template.Template, ref Reference) *template.Template {
+	if ref.Kind != "component" {
+		return tmpl.Lookup(ref.Name)
+	}
+	c, ok := t.components[ref.Name]
+	if !ok {
+		return nil
+	}
+	return tmpl.Lookup(c.template)
+}
+
+// walkTree calls fn for node and every node below it.
+func walkTree(node parse.Node, fn func(parse.Node)) {
+	if node == nil {
+		return
+	}
+	fn(node)
+	switch node := node.(type) {
+	case *parse.ListNode:
+		for _, n := range node.Nodes {
+			walkTree(n, fn)
+		}
+	case *parse.ActionNode:
+		walkTree(node.Pipe, fn)
+	case *parse.TemplateNode:
+		if node.Pipe != nil {
+			walkTree(node.Pipe, fn)
+		}
+	case *parse.PipeNode:
+		for _, cmd := range node.Cmds {
+			walkTree(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			walkTree(arg, fn)
+		}
+	case *parse.IfNode:
+		walkBranch(&node.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&node.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&node.BranchNode, fn)
+	}
+}
+
+func walkBranch(node *parse.BranchNode, fn func(parse.Node)) {
+	walkTree(node.Pipe, fn)
+	if node.List != nil {
+		walkTree(node.List, fn)
+	}
+	if node.ElseList != nil {
+		walkTree(node.ElseList, fn)
+	}
+}
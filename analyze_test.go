@@ -0,0 +1,85 @@
+package tmpl
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAnalyze(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html": {Data: []byte(`<button>{{ template "components/icon" }}</button>`)},
+		"components/icon.html":   {Data: []byte(`<svg></svg>`)},
+		"components/card.html":   {Data: []byte(`{{ define "card" }}<div>{{ slot .Children }}</div>{{ end }}`)},
+		"components/unused.html": {Data: []byte(`<hr>`)},
+		"pages/layout.html":      {Data: []byte(`<main>{{ template "content" . }}</main>`)},
+		"pages/index.html": {Data: []byte(`{{ define "content" }}
+{{ template "components/button" }}
+{{ if . }}{{ slot (tmpl "card" .) }}{{ end }}
+{{ stream "data" .Data }}
+{{ template "components/missing" }}
+{{ end }}
+{{ define "data" }}{{ . }}{{ end }}
+{{ define "data:pending" }}loading{{ end }}
+{{ define "other:error" }}{{ . }}{{ end }}`)},
+	}
+	analysis := New(fs).Preprocess(BlockPreprocessor).Autoload("components").LoadTree("pages").MustParse().Analyze()
+
+	expectedMissing := []Reference{
+		{Name: "components/missing", Kind: "template", From: "content", Location: "pages/index:5:12"},
+	}
+	if !slices.Equal(expectedMissing, analysis.Missing) {
+		t.Errorf("expected: %v, got: %v", expectedMissing, analysis.Missing)
+	}
+	if expected := []string{"components/unused"}; !slices.Equal(expected, analysis.Unused) {
+		t.Errorf("expected: %v, got: %v", expected, analysis.Unused)
+	}
+	if expected := []string{"other:error"}; !slices.Equal(expected, analysis.Orphans) {
+		t.Errorf("expected: %v, got: %v", expected, analysis.Orphans)
+	}
+}
+
+func TestAnalyzeRender(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/card.html":  {Data: []byte(`<div>{{ slot .children }}</div>`)},
+		"components/alert.html": {Data: []byte(`<p>{{ . }}</p>`)},
+		"pages/index.html":      {Data: []byte("{{ #render \"components/card\" }}<p>{{ . }}</p>{{ /render }}\n{{ render \"components/alert\" . }}\n{{ render \"components/missing\" }}")},
+	}
+	analysis := New(fs).Preprocess(BlockPreprocessor).Autoload("components").LoadTree("pages").MustParse().Analyze()
+
+	expectedMissing := []Reference{
+		{Name: "components/missing", Kind: "render", From: "pages/index", Location: "pages/index:3:3"},
+	}
+	if !slices.Equal(expectedMissing, analysis.Missing) {
+		t.Errorf("expected: %v, got: %v", expectedMissing, analysis.Missing)
+	}
+	if len(analysis.Unused) > 0 {
+		t.Errorf("expected no unused templates, got: %v", analysis.Unused)
+	}
+}
+
+func TestAnalyzeComponents(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html": {Data: []byte(`<button>{{ .Label }}</button>`)},
+		"components/card.html":   {Data: []byte(`<div>{{ slot .Children }}</div>`)},
+		"pages/index.html":       {Data: []byte("{{ component \"button\" (map \"label\" \"Save\") }}\n{{ component \"unknown\" }}{{ component \"broken\" }}")},
+	}
+	analysis := New(fs).
+		Component("button", "components/button", nil).
+		Component("broken", "components/missing", nil).
+		Autoload("components").
+		LoadTree("pages").
+		MustParse().
+		Analyze()
+
+	expectedMissing := []Reference{
+		{Name: "broken", Kind: "component", From: "pages/index", Location: "pages/index:2:28"},
+		{Name: "unknown", Kind: "component", From: "pages/index", Location: "pages/index:2:3"},
+	}
+	if !slices.Equal(expectedMissing, analysis.Missing) {
+		t.Errorf("expected: %v, got: %v", expectedMissing, analysis.Missing)
+	}
+	if expected := []string{"components/card"}; !slices.Equal(expected, analysis.Unused) {
+		t.Errorf("expected: %v, got: %v", expected, analysis.Unused)
+	}
+}
@@ -26,23 +26,22 @@ func NewAsyncValue[T, E any](r Renderer) AsyncValue[T, E] {
 }
 
 type asyncValue[T, E any] struct {
-	r     Renderer
-	done  chan struct{}
-	data  streamData
-	isset bool
+	r    Renderer
+	done chan struct{}
+	data streamData
 }
 
 // Ok sets the stream data to a success value and closes the channel.
-// isset indicates the stream data has been set and is not the default value.
+// The stream data is set before the channel is closed so it is safe to read once the channel is closed.
 func (a *asyncValue[T, E]) Ok(data T) {
-	a.data, a.isset = streamData{true, data}, true
+	a.data = streamData{true, data}
 	close(a.done)
 }
 
 // Err sets the stream data to an error value and closes the channel.
-// isset indicates the stream data has been set and is not the default value.
+// The stream data is set before the channel is closed so it is safe to read once the channel is closed.
 func (a *asyncValue[T, E]) Err(err E) {
-	a.data, a.isset = streamData{false, err}, true
+	a.data = streamData{false, err}
 	close(a.done)
 }
 
@@ -52,8 +51,16 @@ func (a *asyncValue[T, E]) get() streamData {
 	return a.data
 }
 
-// getCached returns the stream data and a boolean indicating the stream data has been set.
-func (a *asyncValue[T, E]) getCached() (streamData, bool) { return a.data, a.isset }
+// getCached returns the stream data and a boolean indicating the stream data has been set,
+// it does not block if the done channel is not yet closed.
+func (a *asyncValue[T, E]) getCached() (streamData, bool) {
+	select {
+	case <-a.done:
+		return a.data, true
+	default:
+		return streamData{}, false
+	}
+}
 
 // doneChan returns a done channel that will be closed once the stream data is set.
 func (a *asyncValue[T, E]) doneChan() chan struct{} { return a.done }
@@ -0,0 +1,248 @@
+package tmpl
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// attrName matches valid attribute names.
+var attrName = regexp.MustCompile(`^[a-zA-Z_:@][-a-zA-Z0-9_:.@]*$`)
+
+// attrSpace are the HTML whitespace characters that separate attributes.
+const attrSpace = " \t\n\f\r"
+
+// urlAttrs are attributes with URL values, their values are sanitized like html/template sanitizes URLs.
+var urlAttrs = []string{"href", "src", "action", "formaction", "poster", "cite", "background", "data", "xlink:href"}
+
+// trustedAttr is an attribute value from a template.HTMLAttr built by attrs which is already sanitized.
+type trustedAttr string
+
+// attrs is an ordered set of attributes.
+type attrs struct {
+	keys    []string
+	values  map[string]any
+	classes []any
+}
+
+// set sets the attribute key to value, false or nil values remove the attribute.
+// Class values are merged with clsx.
+func (a *attrs) set(key string, value any) error {
+	if !attrName.MatchString(key) {
+		return fmt.Errorf("invalid attribute name %q", key)
+	}
+	if key == "class" {
+		if s, ok := value.(trustedAttr); ok {
+			value = string(s)
+		}
+		switch value {
+		case nil, false:
+			a.classes = nil
+		case true:
+			return nil
+		default:
+			a.classes = append(a.classes, value)
+			value = true
+		}
+	}
+	if value == nil || value == false {
+		a.values[key] = nil
+		return nil
+	}
+	if !slices.Contains(a.keys, key) {
+		a.keys = append(a.keys, key)
+	}
+	if key == "style" {
+		if prev, ok := a.values[key].(string); ok && prev != "" {
+			value = strings.TrimSuffix(prev, ";") + "; " + fmt.Sprint(value)
+		}
+	}
+	a.values[key] = value
+	return nil
+}
+
+// spread sets every attribute in the map or template.HTMLAttr.
+func (a *attrs) spread(v any) (bool, error) {
+	switch v := v.(type) {
+	case template.HTMLAttr:
+		parsed, err := parseAttrs(string(v))
+		if err != nil {
+			return true, err
+		}
+		for _, attr := range parsed {
+			if err := a.set(attr.name, attr.value); err != nil {
+				return true, err
+			}
+		}
+		return true, nil
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			if err := a.set(k, v[k]); err != nil {
+				return true, err
+			}
+		}
+		return true, nil
+	case Map:
+		return a.spread(map[string]any(v))
+	}
+	return false, nil
+}
+
+// parsedAttr is an attribute parsed from a template.HTMLAttr, the value is true for attributes without a value.
+type parsedAttr struct {
+	name  string
+	value any
+}
+
+// parseAttrs parses the attributes of a template.HTMLAttr with double quoted, single quoted, unquoted or no values.
+// Attributes that cannot be parsed are an error instead of being split or mangled.
+func parseAttrs(s string) ([]parsedAttr, error) {
+	var parsed []parsedAttr
+	for {
+		s = strings.TrimLeft(s, attrSpace)
+		if s == "" {
+			return parsed, nil
+		}
+		end := strings.IndexAny(s, attrSpace+"=")
+		if end < 0 {
+			end = len(s)
+		}
+		name := s[:end]
+		if !attrName.MatchString(name) {
+			return nil, fmt.Errorf("cannot spread attribute %q", name)
+		}
+		s = strings.TrimLeft(s[end:], attrSpace)
+		if !strings.HasPrefix(s, "=") {
+			parsed = append(parsed, parsedAttr{name, true})
+			continue
+		}
+		s = strings.TrimLeft(s[1:], attrSpace)
+		var value string
+		switch {
+		case s == "":
+			return nil, fmt.Errorf("cannot spread attribute %s without a value after =", name)
+		case s[0] == '"' || s[0] == '\'':
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				return nil, fmt.Errorf("cannot spread attribute %s with an unterminated value", name)
+			}
+			value, s = s[1:end+1], s[end+2:]
+			if s != "" && !strings.ContainsRune(attrSpace, rune(s[0])) {
+				return nil, fmt.Errorf("cannot spread attribute %s, expected whitespace after the value", name)
+			}
+		default:
+			end := strings.IndexAny(s, attrSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+			if strings.ContainsAny(value, "\"'=<>`") {
+				return nil, fmt.Errorf("cannot spread attribute %s with unquoted value %q", name, value)
+			}
+		}
+		parsed = append(parsed, parsedAttr{name, trustedAttr(html.UnescapeString(value))})
+	}
+}
+
+// attrsFunc builds HTML attributes from key value pairs, maps and attributes built by attrs.
+//
+// True values render the attribute without a value, false and nil values remove the attribute.
+// Class values are merged with clsx and style values are appended, any other attribute is overridden by later values.
+// Event handler attributes require template.JS values and URL attributes are sanitized.
+func attrsFunc(args ...any) (template.HTMLAttr, error) {
+	a := attrs{values: make(map[string]any)}
+	for i := 0; i < len(args); i++ {
+		if args[i] == nil {
+			continue
+		}
+		if ok, err := a.spread(args[i]); err != nil {
+			return "", err
+		} else if ok {
+			continue
+		}
+		key, ok := args[i].(string)
+		if !ok {
+			return "", fmt.Errorf("expected string key or attributes found %T", args[i])
+		}
+		if i+1 >= len(args) {
+			return "", fmt.Errorf("key %v missing value", key)
+		}
+		i++
+		if err := a.set(key, args[i]); err != nil {
+			return "", err
+		}
+	}
+
+	var sb strings.Builder
+	for _, key := range a.keys {
+		value := a.values[key]
+		if value == nil {
+			continue
+		}
+		if key == "class" {
+			class, err := clsxFunc(a.classes...)
+			if err != nil {
+				return "", err
+			}
+			if class == "" {
+				continue
+			}
+			value = class
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(key)
+		if value == true {
+			continue
+		}
+		s, err := attrValue(key, value)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, `="%s"`, html.EscapeString(s))
+	}
+	return template.HTMLAttr(sb.String()), nil
+}
+
+// attrValue returns the string value of the attribute key.
+func attrValue(key string, value any) (string, error) {
+	if s, ok := value.(trustedAttr); ok {
+		return string(s), nil
+	}
+	lower := strings.ToLower(key)
+	switch {
+	case strings.HasPrefix(lower, "on"):
+		js, ok := value.(template.JS)
+		if !ok {
+			return "", fmt.Errorf("attribute %s expects template.JS found %T", key, value)
+		}
+		return string(js), nil
+	case slices.Contains(urlAttrs, lower):
+		if u, ok := value.(template.URL); ok {
+			return string(u), nil
+		}
+		s := fmt.Sprint(value)
+		if u, err := url.Parse(strings.TrimSpace(s)); err != nil ||
+			(u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "mailto" && u.Scheme != "tel") {
+			return "#ZgotmplZ", nil
+		}
+		return s, nil
+	case lower == "style":
+		if css, ok := value.(template.CSS); ok {
+			return string(css), nil
+		}
+		s := fmt.Sprint(value)
+		if l := strings.ToLower(s); strings.Contains(l, "expression(") || strings.Contains(l, "url(") ||
+			strings.ContainsAny(s, `<>\`) {
+			return "ZgotmplZ", nil
+		}
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,133 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// BlockPreprocessor rewrites block calls into regular calls in templates that use the default delimiters.
+// Block syntax is opt-in, register it with Preprocess(tmpl.BlockPreprocessor).
+//
+// Use NewBlockPreprocessor for templates with delimiters set with Delims.
+var BlockPreprocessor = NewBlockPreprocessor("{{", "}}")
+
+// NewBlockPreprocessor returns a preprocessor that rewrites block calls delimited by left and right into regular calls.
+//
+// A block call {{ #func args }}body{{ /func }} calls func with args and the body as the last argument,
+// ie. {{ #render "components/card" (map "title" "x") }}<p>{{ .Text }}</p>{{ /render }}.
+// The body is defined as a template named after the file and the block index at the end of the text
+// and passed to func as (tmpl "file:block1" .) so the body is rendered with the dot at the call site.
+// The body is replaced with a comment spanning the same number of lines so line numbers after the block remain correct.
+func NewBlockPreprocessor(left, right string) Extractor {
+	tag := regexp.MustCompile(regexp.QuoteMeta(left) + `(-?)\s*([#/])([A-Za-z_][A-Za-z0-9_]*)\b(.*?)\s*(-?)` + regexp.QuoteMeta(right))
+	return ExtractorFunc(func(file string, content []byte) ([]byte, error) {
+		text, err := expandBlocks(tag, left, right, file, string(content))
+		return []byte(text), err
+	})
+}
+
+// expandBlocks rewrites the block calls matched by tag in the template text of file into regular calls,
+// see NewBlockPreprocessor.
+func expandBlocks(tag *regexp.Regexp, left, right, file, text string) (string, error) {
+	var defines strings.Builder
+	n := 0
+	for {
+		tags := tag.FindAllStringSubmatchIndex(text, -1)
+		// find an innermost block, an open tag immediately followed by it's close tag
+		open, close := -1, -1
+		for i, tag := range tags {
+			if text[tag[4]:tag[5]] == "/" {
+				if i == 0 || text[tags[i-1][4]:tags[i-1][5]] != "#" {
+					return "", blockError(file, text, tag[0], "unexpected close tag %s", text[tag[0]:tag[1]])
+				}
+				if fn := text[tag[6]:tag[7]]; fn != text[tags[i-1][6]:tags[i-1][7]] {
+					return "", blockError(file, text, tag[0], "close tag %s does not match #%s", text[tag[0]:tag[1]], text[tags[i-1][6]:tags[i-1][7]])
+				}
+				open, close = i-1, i
+				break
+			}
+		}
+		if open < 0 {
+			if len(tags) > 0 {
+				tag := tags[len(tags)-1]
+				return "", blockError(file, text, tag[0], "block #%s is not closed", text[tag[6]:tag[7]])
+			}
+			break
+		}
+		n++
+		o, c := tags[open], tags[close]
+		body := text[o[1]:c[0]]
+		if text[o[10]:o[11]] == "-" {
+			body = strings.TrimLeft(body, " \t\r\n")
+		}
+		if text[c[2]:c[3]] == "-" {
+			body = strings.TrimRight(body, " \t\r\n")
+		}
+		blockName := fmt.Sprintf("%s:block%d", file, n)
+		fmt.Fprintf(&defines, "%s define %q %s%s%s end %s", left, blockName, right, body, left, right)
+		call := fmt.Sprintf("%s%s %s%s (tmpl %q .) %s%s", left, text[o[2]:o[3]], text[o[6]:o[7]], text[o[8]:o[9]], blockName, text[c[10]:c[11]], right)
+		if lines := strings.Count(text[o[0]:c[1]], "\n"); lines > 0 {
+			call += left + "/*" + strings.Repeat("\n", lines) + "*/" + right
+		}
+		text = text[:o[0]] + call + text[c[1]:]
+	}
+	return text + defines.String(), nil
+}
+
+// blockError returns a template error at the position pos in text.
+func blockError(name, text string, pos int, format string, args ...any) error {
+	line := strings.Count(text[:pos], "\n") + 1
+	return fmt.Errorf("template: %s:%d: %s", name, line, fmt.Sprintf(format, args...))
+}
+
+// renderFunc returns the render func which renders a template with data and children.
+//
+// Children are set to the "children" key of map data or passed to the Wrap method of Layout data.
+func renderFunc(t *template.Template) any {
+	return func(name string, args ...any) (template.HTML, error) {
+		if len(args) > 2 {
+			return "", fmt.Errorf("render %q: expected data and children found %d arguments", name, len(args))
+		}
+		var data, children any
+		if len(args) > 0 {
+			data = args[0]
+		}
+		if len(args) > 1 {
+			children = args[1]
+		}
+		if children != nil {
+			switch d := data.(type) {
+			case nil:
+				data = map[string]any{"children": children}
+			case map[string]any:
+				m := make(map[string]any, len(d)+1)
+				for k, v := range d {
+					m[k] = v
+				}
+				m["children"] = children
+				data = m
+			case Map:
+				m := make(Map, len(d)+1)
+				for k, v := range d {
+					m[k] = v
+				}
+				m["children"] = children
+				data = m
+			case Layout:
+				tp, ok := children.(Template)
+				if !ok {
+					return "", fmt.Errorf("render %q: children must be a Template found %T", name, children)
+				}
+				d.Wrap(tp)
+			default:
+				return "", fmt.Errorf("render %q: cannot set children on data of type %T", name, data)
+			}
+		}
+		var buf bytes.Buffer
+		err := t.ExecuteTemplate(&buf, name, data)
+		return template.HTML(buf.String()), err
+	}
+}
@@ -0,0 +1,105 @@
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBlocks(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/card.html":   {Data: []byte(`<div><h2>{{ .title }}</h2>{{ slot .children }}</div>`)},
+		"components/button.html": {Data: []byte(`<button>{{ slot .children }}</button>`)},
+		"index.html": {Data: []byte(`{{ range . -}}
+{{ #render "components/card" (map "title" .Title) -}}
+	<p>{{ .Text }}</p>
+	{{- #render "components/button" nil }}{{ .Title }}{{ /render }}
+{{- /render }}
+{{ end }}`)},
+		"props.html": {Data: []byte(`{{ #component "panel" (props "title" "Panel") }}<b>{{ . }}</b>{{ /component }}`)},
+	}
+	templates := New(fs).
+		Preprocess(BlockPreprocessor).
+		Component("panel", "components/card", nil).
+		Autoload("components").
+		Load("index").
+		Load("props").
+		MustParse()
+
+	tests := []struct {
+		template Template
+		expected string
+	}{
+		{
+			template: Tmpl("index", []Map{{"Title": "A", "Text": "a"}, {"Title": "B", "Text": "b"}}),
+			expected: "<div><h2>A</h2><p>a</p><button>A</button></div>\n<div><h2>B</h2><p>b</p><button>B</button></div>\n",
+		},
+		{
+			template: Tmpl("props", "x"),
+			expected: "<div><h2>Panel</h2><b>x</b></div>",
+		},
+	}
+	buf := new(bytes.Buffer)
+	for _, test := range tests {
+		if err := templates.Render(buf, test.template); err != nil {
+			t.Error(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("expected: %q, got: %q", test.expected, buf.String())
+		}
+		buf.Reset()
+	}
+}
+
+func TestBlockErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		line int
+		err  string
+	}{
+		{"<p>\n{{ #render \"card\" }}\n", 2, `block #render is not closed`},
+		{"{{ #render \"card\" }}\n{{ /component }}", 2, `close tag {{ /component }} does not match #render`},
+		{"\n\n{{ /render }}", 3, `unexpected close tag {{ /render }}`},
+		// line numbers after a block are preserved
+		{"{{ #render \"card\" }}\n\n{{ /render }}\n{{ end }}", 4, `unexpected {{end}}`},
+	}
+	for _, test := range tests {
+		_, err := New(fstest.MapFS{"page.html": {Data: []byte(test.text)}}).Preprocess(BlockPreprocessor).Load("page").Parse()
+		var le *LoadError
+		if !errors.As(err, &le) || le.Line != test.line || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("expected error at line %d: %q, got: %v", test.line, test.err, err)
+		}
+	}
+}
+
+func TestBlocksDelims(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/card.html": {Data: []byte(`<div>[[ slot .children ]]</div>`)},
+		"index.html":           {Data: []byte(`[[ #render "components/card" nil ]]<p>[[ . ]]</p>[[ /render ]]<script type="text/x-handlebars">{{#each items}}<li>{{this}}</li>{{/each}}</script>`)},
+		"plain.html":           {Data: []byte(`<script type="text/x-handlebars">{{#each items}}<li>{{this}}</li>{{/each}}</script>`)},
+	}
+	delims := func(name string, t *template.Template) { t.Delims("[[", "]]") }
+
+	// block syntax is opt-in so literal text with block tags is not rewritten
+	templates := New(fs).OnLoad(delims).Load("plain").MustParse()
+	buf := new(bytes.Buffer)
+	if err := templates.Render(buf, Tmpl("plain", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<script type="text/x-handlebars">{{#each items}}<li>{{this}}</li>{{/each}}</script>`; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+
+	// block calls use the delimiters of the preprocessor
+	templates = New(fs).OnLoad(delims).Preprocess(NewBlockPreprocessor("[[", "]]")).Load("components/card", "index").MustParse()
+	buf.Reset()
+	if err := templates.Render(buf, Tmpl("index", "x")); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<div><p>x</p></div><script type="text/x-handlebars">{{#each items}}<li>{{this}}</li>{{/each}}</script>`; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+}
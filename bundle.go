@@ -0,0 +1,279 @@
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Bundle is a precompiled set of template files.
+//
+// A bundle stores the template text of every file after it is extracted, stripped of frontmatter and preprocessed,
+// the files found by walking each directory passed to Autoload and LoadTree and the message catalogs loaded with I18n.
+// Use FromBundle to load templates from a bundle without reading a filesystem.
+//
+// Bundles are usually written as a generated Go file with the bundle command of cmd/tmpl.
+type Bundle struct {
+	// Files are the bundled template files by template name.
+	Files map[string]BundleFile
+
+	// Autoload are the template names found in each directory passed to Autoload.
+	Autoload map[string][]string
+
+	// Trees are the file groups found in each directory passed to LoadTree by template name,
+	// each file group contains the layout and special template names and the template file name.
+	Trees map[string]map[string][]string
+
+	// Catalogs are the contents of the message catalogs found in each directory passed to I18n by file name.
+	Catalogs map[string]map[string]string
+}
+
+// BundleFile is a bundled template file.
+type BundleFile struct {
+	// Path is the filepath of the source file.
+	Path string
+
+	// Ext is the file extension of the source file.
+	Ext string
+
+	// Layer is the index of the filesystem layer the file was loaded from when using Overlay.
+	Layer int
+
+	// Meta is the frontmatter of the file.
+	Meta map[string]any
+
+	// Text is the template text of the file.
+	Text string
+}
+
+// FromBundle initializes a new templates parser that loads templates from a bundle instead of a filesystem.
+//
+// Autoload, LoadTree and I18n load the files recorded for each directory in the bundle,
+// directories that are not in the bundle are reported as load errors.
+// The template text in the bundle is already extracted and preprocessed, so extractors and preprocessors are not applied.
+func FromBundle(b *Bundle) *templatesParser {
+	t := New(nil)
+	t.bundle = b
+	return t
+}
+
+// Bundle returns a bundle of all templates loaded with Autoload, Load and LoadTree and the message catalogs loaded with I18n.
+//
+// Bundle reads the template files again, so it returns an error if loading any of the templates returned an error
+// or if a template file can no longer be read. Bundle does not parse templates,
+// use Lazy so Load and LoadTree only walk the files when the parser is only used to create a bundle.
+func (t *templatesParser) Bundle() (*Bundle, error) {
+	if len(t.loadErrs) == 1 {
+		return nil, t.loadErrs[0]
+	}
+	if len(t.loadErrs) > 1 {
+		return nil, errors.Join(t.loadErrs...)
+	}
+	b := &Bundle{
+		Files:    make(map[string]BundleFile, len(t.walks.Files)),
+		Autoload: maps.Clone(t.walks.Autoload),
+		Trees:    maps.Clone(t.walks.Trees),
+		Catalogs: maps.Clone(t.walks.Catalogs),
+	}
+	r := t.reader()
+	for name := range t.walks.Files {
+		file, text, err := r.read(name)
+		if err != nil {
+			return nil, newLoadError(name, file.Path, err)
+		}
+		b.Files[name] = BundleFile{Path: file.Path, Ext: file.Ext, Layer: file.Layer, Meta: file.Meta, Text: text}
+	}
+	return b, nil
+}
+
+// recordFiles records the template names to be included in the bundle.
+func (t *templatesParser) recordFiles(names ...string) {
+	for _, name := range names {
+		t.walks.Files[name] = BundleFile{}
+	}
+}
+
+// autoloadFiles returns the template names in dir for Autoload from the bundle or by walking the filesystem.
+func (t *templatesParser) autoloadFiles(dir string) ([]string, error) {
+	var files []string
+	var err error
+	if t.bundle != nil {
+		var ok bool
+		if files, ok = t.bundle.Autoload[dir]; !ok {
+			err = newLoadError(dir, dir, &fs.PathError{Op: "bundle", Path: dir, Err: fs.ErrNotExist})
+		}
+	} else {
+		files, err = walkFiles(t.fsys, t.exts, []string{dir})
+	}
+	if err == nil {
+		t.walks.Autoload[dir] = files
+		t.recordFiles(files...)
+	}
+	return files, err
+}
+
+// treeFiles returns the file groups in dir for LoadTree from the bundle or by walking the filesystem.
+func (t *templatesParser) treeFiles(dir string) (map[string][]string, error) {
+	dir = strings.TrimSuffix(dir, "/")
+	var groups map[string][]string
+	var err error
+	if t.bundle != nil {
+		var ok bool
+		if groups, ok = t.bundle.Trees[dir]; !ok {
+			err = newLoadError(dir, dir, &fs.PathError{Op: "bundle", Path: dir, Err: fs.ErrNotExist})
+		}
+	} else {
+		groups, err = walkFilesWithLayout(t.fsys, t.exts, t.layoutFilename, dir)
+	}
+	if err == nil {
+		t.walks.Trees[dir] = groups
+		for _, files := range groups {
+			t.recordFiles(files...)
+		}
+	}
+	return groups, err
+}
+
+// catalogFiles returns the contents of the message catalogs in dir for I18n by file name from the bundle or by reading the filesystem.
+func (t *templatesParser) catalogFiles(dir string) (map[string]string, error) {
+	if t.bundle != nil {
+		files, ok := t.bundle.Catalogs[dir]
+		if !ok {
+			return nil, newLoadError(dir, dir, &fs.PathError{Op: "bundle", Path: dir, Err: fs.ErrNotExist})
+		}
+		t.walks.Catalogs[dir] = files
+		return files, nil
+	}
+	if t.fsys == nil {
+		return nil, &fs.PathError{Op: "open", Path: dir, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(t.fsys, dir)
+	if err != nil {
+		return nil, newLoadError(dir, dir, err)
+	}
+	files := make(map[string]string)
+	for _, entry := range entries {
+		if ext := path.Ext(entry.Name()); entry.IsDir() || (ext != ".json" && ext != ".po") {
+			continue
+		}
+		file := path.Join(dir, entry.Name())
+		b, err := fs.ReadFile(t.fsys, file)
+		if err != nil {
+			return nil, newLoadError(file, file, err)
+		}
+		files[entry.Name()] = string(b)
+	}
+	t.walks.Catalogs[dir] = files
+	return files, nil
+}
+
+// WriteGo writes the bundle as a Go source file of package pkg that declares the bundle as the variable name.
+func (b *Bundle) WriteGo(w io.Writer, pkg, name string) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by tmpl bundle. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import \"github.com/eriicafes/tmpl\"\n\n")
+	fmt.Fprintf(&buf, "var %s = &tmpl.Bundle{\n", name)
+
+	buf.WriteString("Files: map[string]tmpl.BundleFile{\n")
+	for _, name := range slices.Sorted(maps.Keys(b.Files)) {
+		file := b.Files[name]
+		fmt.Fprintf(&buf, "%q: {\nPath: %q,\nExt: %q,\n", name, file.Path, file.Ext)
+		if file.Layer != 0 {
+			fmt.Fprintf(&buf, "Layer: %d,\n", file.Layer)
+		}
+		if file.Meta != nil {
+			fmt.Fprintf(&buf, "Meta: %s,\n", goValue(file.Meta))
+		}
+		fmt.Fprintf(&buf, "Text: %s,\n},\n", goString(file.Text))
+	}
+	buf.WriteString("},\n")
+
+	buf.WriteString("Autoload: map[string][]string{\n")
+	for _, dir := range slices.Sorted(maps.Keys(b.Autoload)) {
+		fmt.Fprintf(&buf, "%q: %s,\n", dir, goStrings(b.Autoload[dir]))
+	}
+	buf.WriteString("},\n")
+
+	buf.WriteString("Trees: map[string]map[string][]string{\n")
+	for _, dir := range slices.Sorted(maps.Keys(b.Trees)) {
+		fmt.Fprintf(&buf, "%q: {\n", dir)
+		for _, name := range slices.Sorted(maps.Keys(b.Trees[dir])) {
+			fmt.Fprintf(&buf, "%q: %s,\n", name, goStrings(b.Trees[dir][name]))
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("},\n")
+
+	if len(b.Catalogs) > 0 {
+		buf.WriteString("Catalogs: map[string]map[string]string{\n")
+		for _, dir := range slices.Sorted(maps.Keys(b.Catalogs)) {
+			fmt.Fprintf(&buf, "%q: {\n", dir)
+			for _, name := range slices.Sorted(maps.Keys(b.Catalogs[dir])) {
+				fmt.Fprintf(&buf, "%q: %s,\n", name, goString(b.Catalogs[dir][name]))
+			}
+			buf.WriteString("},\n")
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// goString returns s as a raw string literal if possible, otherwise as an interpreted string literal.
+func goString(s string) string {
+	if strings.ContainsAny(s, "`\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// goValue returns a frontmatter value as a Go literal.
+func goValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return "float64(" + strconv.FormatFloat(v, 'g', -1, 64) + ")"
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = goValue(item)
+		}
+		return "[]any{" + strings.Join(items, ", ") + "}"
+	case map[string]any:
+		var sb strings.Builder
+		sb.WriteString("map[string]any{")
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			fmt.Fprintf(&sb, "%q: %s, ", k, goValue(v[k]))
+		}
+		sb.WriteString("}")
+		return sb.String()
+	default:
+		return fmt.Sprintf("%#v", v)
+	}
+}
+
+// goStrings returns ss as a string slice literal.
+func goStrings(ss []string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = strconv.Quote(s)
+	}
+	return "{" + strings.Join(quoted, ", ") + "}"
+}
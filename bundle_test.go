@@ -0,0 +1,102 @@
+package tmpl
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBundle(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.go": {Data: []byte("package components\n\nfunc init() {\n\ttmpl.Define(`<button>{{ . }}</button>`)\n}\n")},
+		"pages/layout.html":    {Data: []byte("---\ntitle: Site\n---\n<title>{{ meta \"title\" }}</title>{{ template \"content\" . }}")},
+		"pages/index.html":     {Data: []byte("{{ define \"content\" }}{{ template \"components/button\" \"ok\" }}{{ end }}")},
+		"partials/footer.html": {Data: []byte("<footer>`{{ . }}`</footer>")},
+	}
+	p := New(fs).SetExt("html", "go").Frontmatter("html").Autoload("components").LoadTree("pages").Load("partials/footer")
+	b, err := p.Bundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := b.Files["components/button"].Text; text != "<button>{{ . }}</button>" {
+		t.Errorf("expected extracted text, got: %q", text)
+	}
+	if title := b.Files["pages/layout"].Meta["title"]; title != "Site" {
+		t.Errorf("expected: %q, got: %q", "Site", title)
+	}
+
+	var sb strings.Builder
+	if err := b.WriteGo(&sb, "templates", "Bundle"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "bundle.go", sb.String(), 0); err != nil {
+		t.Errorf("expected valid go source, got: %v\n%s", err, sb.String())
+	}
+
+	render := func(templates Templates, tp Template) string {
+		var sb strings.Builder
+		if err := templates.Render(&sb, tp); err != nil {
+			t.Fatal(err)
+		}
+		return sb.String()
+	}
+	eager := p.MustParse()
+	bundled := FromBundle(b).Autoload("components").LoadTree("pages").Load("partials/footer").MustParse()
+	for _, tp := range []Template{
+		Associated("pages/index", "pages/layout", nil),
+		Tmpl("partials/footer", "x"),
+	} {
+		if expected, got := render(eager, tp), render(bundled, tp); expected != got {
+			t.Errorf("expected: %q, got: %q", expected, got)
+		}
+	}
+	if meta, _ := bundled.Metadata("pages/index"); len(meta.Layouts) != 1 || meta.Files[0].Path != "pages/layout.html" {
+		t.Errorf("expected bundled metadata, got: %v", meta)
+	}
+
+	_, err = FromBundle(b).LoadTree("missing").Parse()
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected missing directory error, got: %v", err)
+	}
+}
+
+func TestBundleCatalogs(t *testing.T) {
+	fs := fstest.MapFS{
+		"locales/en.json":  {Data: []byte(`{"hello": "Hello"}`)},
+		"locales/fr.po":    {Data: []byte("msgid \"hello\"\nmsgstr \"Bonjour\"")},
+		"pages/index.html": {Data: []byte(`{{ t "hello" }}`)},
+	}
+	b, err := New(fs).Lazy(true).I18n("locales", "en").LoadTree("pages").Bundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Catalogs["locales"]) != 2 {
+		t.Errorf("expected bundled catalogs, got: %v", b.Catalogs)
+	}
+	var sb strings.Builder
+	if err := b.WriteGo(&sb, "templates", "Bundle"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), `"fr.po": `) {
+		t.Errorf("expected catalogs in go source, got:\n%s", sb.String())
+	}
+
+	templates, err := FromBundle(b).I18n("locales", "en").LoadTree("pages").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb.Reset()
+	if err := templates.WithLocale("fr").Render(&sb, Tmpl("pages/index", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Bonjour"; sb.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, sb.String())
+	}
+
+	_, err = FromBundle(b).I18n("missing", "en").Parse()
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected missing directory error, got: %v", err)
+	}
+}
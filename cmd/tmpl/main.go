@@ -0,0 +1,178 @@
+// Command tmpl provides tooling for templates loaded with the tmpl package.
+//
+// Usage:
+//
+//	tmpl graph [flags]
+//	tmpl bundle [flags]
+//
+// Templates are loaded from the directory set by -dir, the same way as the tmpl parser
+// loads them with Autoload and LoadTree. Functions added with Funcs are not available to the command,
+// so templates are parsed with SkipFuncCheck.
+//
+// The graph command prints the dependency graph of the templates as DOT or JSON.
+//
+//	tmpl graph -autoload components -tree pages | dot -Tsvg > graph.svg
+//
+// The bundle command writes the templates to a generated Go file that declares a *tmpl.Bundle,
+// load the bundle with tmpl.FromBundle. It is usually run with go generate.
+//
+//	//go:generate go run github.com/eriicafes/tmpl/cmd/tmpl bundle -autoload components -tree pages -o bundle_gen.go
+//
+// Only the default extractors are applied and no preprocessors except the block preprocessor with -blocks,
+// as extractors and preprocessors are configured in Go.
+// Parsers with custom extractors or preprocessors should write the bundle with Bundle and WriteGo from a go generate program.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/eriicafes/tmpl"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "graph":
+		err = graph(os.Stdout, os.Args[2:])
+	case "bundle":
+		err = bundle(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "tmpl: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tmpl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `usage: tmpl <command> [flags]
+
+commands:
+  graph   print the template dependency graph as DOT or JSON
+  bundle  write the templates to a generated Go file
+
+run "tmpl <command> -h" for the command flags
+`)
+}
+
+// loadFlags are the flags used to configure the templates parser.
+type loadFlags struct {
+	dir         string
+	autoload    string
+	tree        string
+	ext         string
+	layout      string
+	frontmatter string
+	blocks      bool
+}
+
+func (f *loadFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.dir, "dir", ".", "root directory of the templates")
+	fs.StringVar(&f.autoload, "autoload", "", "comma separated directories to autoload")
+	fs.StringVar(&f.tree, "tree", "", "comma separated directories to load with LoadTree")
+	fs.StringVar(&f.ext, "ext", "html", "comma separated template file extensions")
+	fs.StringVar(&f.layout, "layout", "layout", "layout filename")
+	fs.StringVar(&f.frontmatter, "frontmatter", "", "comma separated extensions of template files with frontmatter")
+	fs.BoolVar(&f.blocks, "blocks", false, "rewrite block calls with tmpl.BlockPreprocessor")
+}
+
+// load loads the templates configured by the flags, or only bundles them if bundle is true.
+// Bundles only need the walked and extracted files, so pages are loaded lazily and not parsed.
+func (f *loadFlags) load(bundle bool) (tmpl.Templates, *tmpl.Bundle, error) {
+	p := tmpl.New(os.DirFS(f.dir)).
+		SetExt(splitList(f.ext)...).
+		SetLayoutFilename(f.layout).
+		Frontmatter(splitList(f.frontmatter)...).
+		CollectErrors(true).
+		SkipFuncCheck(true).
+		Lazy(bundle)
+	if f.blocks {
+		p.Preprocess(tmpl.BlockPreprocessor)
+	}
+	if dirs := splitList(f.autoload); len(dirs) > 0 {
+		p.Autoload(dirs...)
+	}
+	for _, dir := range splitList(f.tree) {
+		p.LoadTree(dir)
+	}
+	if bundle {
+		b, err := p.Bundle()
+		return tmpl.Templates{}, b, err
+	}
+	templates, err := p.Parse()
+	return templates, nil, err
+}
+
+func graph(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	var lf loadFlags
+	lf.register(fs)
+	format := fs.String("format", "dot", "output format, dot or json")
+	fs.Parse(args)
+
+	templates, _, err := lf.load(false)
+	if err != nil {
+		return err
+	}
+	g := templates.Graph()
+	switch *format {
+	case "dot":
+		return g.WriteDOT(w)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
+
+func bundle(args []string) error {
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	var lf loadFlags
+	lf.register(fs)
+	out := fs.String("o", "tmpl_bundle.go", "output file")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package name of the output file, defaults to $GOPACKAGE set by go generate")
+	name := fs.String("name", "Bundle", "variable name of the bundle")
+	fs.Parse(args)
+	if *pkg == "" {
+		return fmt.Errorf("bundle: -pkg is required outside of go generate")
+	}
+
+	_, b, err := lf.load(true)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := b.WriteGo(&buf, *pkg, *name); err != nil {
+		return err
+	}
+	return os.WriteFile(*out, buf.Bytes(), 0o644)
+}
+
+// splitList splits a comma separated list and omits empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes the files to dir by path.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGraphCustomFuncs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"components/button.html": `<button>{{ vite "main.ts" }}</button>`,
+		"pages/index.html":       `{{ template "components/button" }}{{ upper (vite "main.ts") }}`,
+	})
+
+	var buf bytes.Buffer
+	if err := graph(&buf, []string{"-dir", dir, "-autoload", "components", "-tree", "pages"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `"pages/index" -> "components/button" [label="template"];`; !strings.Contains(buf.String(), expected) {
+		t.Errorf("expected graph to contain: %q, got: %q", expected, buf.String())
+	}
+}
+
+func TestBundleCustomFuncs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"components/button.html": `<button>{{ vite "main.ts" }}</button>`,
+		"pages/layout.html":      `<main>{{ slot .Children }}</main>`,
+		"pages/index.html":       `{{ template "components/button" }}{{ upper (vite "main.ts") }}`,
+	})
+	out := filepath.Join(dir, "tmpl_bundle.go")
+
+	if err := bundle([]string{"-dir", dir, "-autoload", "components", "-tree", "pages", "-o", out, "-pkg", "views"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"package views", `"pages/index": {"pages/layout", "pages/index"}`, `{{ upper (vite "main.ts") }}`} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected bundle to contain: %q, got: %s", expected, b)
+		}
+	}
+}
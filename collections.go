@@ -0,0 +1,260 @@
+package tmpl
+
+import (
+	"fmt"
+	"html/template"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// listFunc returns the values as a list.
+func listFunc(v ...any) []any {
+	return append([]any{}, v...)
+}
+
+// appendFunc returns a new list with the values appended to the list.
+func appendFunc(list any, v ...any) ([]any, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	return append(items, v...), nil
+}
+
+// mergeFunc returns a new map with the entries of all maps, later maps override earlier maps.
+// Nested maps are merged recursively.
+func mergeFunc(maps ...any) (map[string]any, error) {
+	merged := make(map[string]any)
+	for _, m := range maps {
+		if m == nil {
+			continue
+		}
+		src, err := toMap(m)
+		if err != nil {
+			return nil, err
+		}
+		deepMerge(merged, src)
+	}
+	return merged, nil
+}
+
+func deepMerge(dst, src map[string]any) {
+	for k, v := range src {
+		if srcMap, err := toMap(v); err == nil && v != nil {
+			if dstMap, err := toMap(dst[k]); err == nil && dst[k] != nil {
+				merged := make(map[string]any, len(dstMap))
+				deepMerge(merged, dstMap)
+				deepMerge(merged, srcMap)
+				dst[k] = merged
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+// pickFunc returns a new map with only the keys.
+func pickFunc(m any, keys ...string) (map[string]any, error) {
+	src, err := toMap(m)
+	if err != nil {
+		return nil, err
+	}
+	picked := make(map[string]any, len(keys))
+	for _, k := range keys {
+		if v, ok := src[k]; ok {
+			picked[k] = v
+		}
+	}
+	return picked, nil
+}
+
+// omitFunc returns a new map without the keys.
+func omitFunc(m any, keys ...string) (map[string]any, error) {
+	src, err := toMap(m)
+	if err != nil {
+		return nil, err
+	}
+	omitted := make(map[string]any, len(src))
+	for k, v := range src {
+		omitted[k] = v
+	}
+	for _, k := range keys {
+		delete(omitted, k)
+	}
+	return omitted, nil
+}
+
+// defaultFunc returns value if it is not empty, otherwise def.
+// The value is the last argument so default can be used in pipelines, ie. {{ .Title | default "Untitled" }}.
+func defaultFunc(def any, value ...any) (any, error) {
+	if len(value) > 1 {
+		return nil, fmt.Errorf("expected default and value found %d values", len(value)+1)
+	}
+	if len(value) == 0 {
+		return def, nil
+	}
+	if truthy, _ := template.IsTrue(value[0]); truthy {
+		return value[0], nil
+	}
+	return def, nil
+}
+
+// coalesceFunc returns the first value that is not empty or nil.
+func coalesceFunc(values ...any) any {
+	for _, v := range values {
+		if truthy, _ := template.IsTrue(v); truthy {
+			return v
+		}
+	}
+	return nil
+}
+
+// ternaryFunc returns a if cond is true, otherwise b.
+// The condition is the last argument so ternary can be used in pipelines, ie. {{ .Active | ternary "on" "off" }}.
+func ternaryFunc(a, b any, cond any) any {
+	if truthy, _ := template.IsTrue(cond); truthy {
+		return a
+	}
+	return b
+}
+
+// firstFunc returns the first item of the list or nil if the list is empty.
+func firstFunc(list any) (any, error) {
+	items, err := toList(list)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[0], nil
+}
+
+// lastFunc returns the last item of the list or nil if the list is empty.
+func lastFunc(list any) (any, error) {
+	items, err := toList(list)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return items[len(items)-1], nil
+}
+
+// seqFunc returns a sequence of integers like the seq command, sequences longer than maxSeqLen are an error.
+//
+//	seq last              1 to last
+//	seq first last        first to last, counting down if first is greater than last
+//	seq first incr last   first to last by incr
+func seqFunc(args ...int) ([]int, error) {
+	first, incr, last := 1, 1, 0
+	switch len(args) {
+	case 1:
+		last = args[0]
+	case 2:
+		first, last = args[0], args[1]
+		if first > last {
+			incr = -1
+		}
+	case 3:
+		first, incr, last = args[0], args[1], args[2]
+	default:
+		return nil, fmt.Errorf("expected 1 to 3 arguments found %d", len(args))
+	}
+	if incr == 0 {
+		return nil, fmt.Errorf("increment must not be zero")
+	}
+	// count the values without stepping past last so the increment cannot overflow
+	var span, step uint64
+	switch {
+	case incr > 0 && first <= last:
+		span, step = uint64(last)-uint64(first), uint64(incr)
+	case incr < 0 && first >= last:
+		span, step = uint64(first)-uint64(last), uint64(-(incr+1))+1
+	default:
+		return nil, nil
+	}
+	if span/step >= maxSeqLen {
+		return nil, fmt.Errorf("sequence exceeds the limit of %d values", maxSeqLen)
+	}
+	seq := make([]int, span/step+1)
+	for i := range seq {
+		seq[i] = first + i*incr
+	}
+	return seq, nil
+}
+
+// maxSeqLen is the maximum number of values returned by seq.
+const maxSeqLen = 100_000
+
+// setFunc returns a copy of the map with value set at the dotted key path, creating nested maps as needed.
+// The maps along the key path are copied so the original map is not modified.
+func setFunc(m any, path string, value any) (map[string]any, error) {
+	keys := strings.Split(path, ".")
+	if slices.Contains(keys, "") {
+		return nil, fmt.Errorf("invalid key path %q", path)
+	}
+	return setPath(m, keys, value)
+}
+
+func setPath(m any, keys []string, value any) (map[string]any, error) {
+	src, err := toMap(m)
+	if err != nil {
+		return nil, err
+	}
+	copied := make(map[string]any, len(src)+1)
+	for k, v := range src {
+		copied[k] = v
+	}
+	key := keys[0]
+	if len(keys) == 1 {
+		copied[key] = value
+		return copied, nil
+	}
+	child := src[key]
+	if _, err := toMap(child); err != nil {
+		return nil, fmt.Errorf("key %s expected map found %T", key, child)
+	}
+	v, err := setPath(child, keys[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	copied[key] = v
+	return copied, nil
+}
+
+// toMap returns m as a map with string keys.
+func toMap(m any) (map[string]any, error) {
+	switch m := m.(type) {
+	case map[string]any:
+		return m, nil
+	case Map:
+		return m, nil
+	case nil:
+		return map[string]any{}, nil
+	}
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("expected map found %T", m)
+	}
+	converted := make(map[string]any, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		converted[iter.Key().String()] = iter.Value().Interface()
+	}
+	return converted, nil
+}
+
+// toList returns list as a slice of values.
+func toList(list any) ([]any, error) {
+	switch list := list.(type) {
+	case []any:
+		return append([]any{}, list...), nil
+	case nil:
+		return []any{}, nil
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected list found %T", list)
+	}
+	items := make([]any, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
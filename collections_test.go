@@ -0,0 +1,90 @@
+package tmpl
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestCollections(t *testing.T) {
+	tests := []struct {
+		text   string
+		data   any
+		output string
+		err    string
+	}{
+		// list and append
+		{text: `{{ list 1 "two" 3 }}`, output: "[1 two 3]"},
+		{text: `{{ list }}`, output: "[]"},
+		{text: `{{ append (list 1 2) 3 4 }}`, output: "[1 2 3 4]"},
+		{text: `{{ append . "c" }}`, data: []string{"a", "b"}, output: "[a b c]"},
+		{text: `{{ append nil 1 }}`, output: "[1]"},
+		{text: `{{ append "a" 1 }}`, err: "expected list found string"},
+		// merge
+		{
+			text:   `{{ $m := merge (map "a" 1 "n" (map "x" 1 "y" 2)) (map "b" 2 "n" (map "y" 3)) }}{{ $m.a }} {{ $m.b }} {{ $m.n.x }} {{ $m.n.y }}`,
+			output: "1 2 1 3",
+		},
+		{text: `{{ $m := merge (map "n" (map "x" 1)) (map "n" 2) }}{{ $m.n }}`, output: "2"},
+		{text: `{{ $m := merge . (map "b" 2) }}{{ $m.a }} {{ $m.b }}`, data: map[string]int{"a": 1}, output: "1 2"},
+		{text: `{{ merge (map "a" 1) 2 }}`, err: "expected map found int"},
+		// pick and omit
+		{text: `{{ pick (map "a" 1 "b" 2 "c" 3) "a" "c" "d" }}`, output: "map[a:1 c:3]"},
+		{text: `{{ omit (map "a" 1 "b" 2 "c" 3) "a" "c" }}`, output: "map[b:2]"},
+		{text: `{{ pick (list 1) "a" }}`, err: "expected map found []interface {}"},
+		// set
+		{text: `{{ set (map "a" 1) "b" 2 }}`, output: "map[a:1 b:2]"},
+		{text: `{{ set (map "a" (map "b" 1)) "a.c.d" 2 }}`, output: "map[a:map[b:1 c:map[d:2]]]"},
+		{text: `{{ set nil "a.b" 1 }}`, output: "map[a:map[b:1]]"},
+		{text: `{{ $m := map "a" (map "b" 1) }}{{ $_ := set $m "a.b" 2 }}{{ $m }}`, output: "map[a:map[b:1]]"},
+		{text: `{{ set (map "a" 1) "a.b" 2 }}`, err: "key a expected map found int"},
+		{text: `{{ set nil "a..b" 2 }}`, err: `invalid key path "a..b"`},
+		// default, coalesce and ternary
+		{text: `{{ .Title | default "Untitled" }}`, data: map[string]any{"Title": ""}, output: "Untitled"},
+		{text: `{{ .Title | default "Untitled" }}`, data: map[string]any{"Title": "Home"}, output: "Home"},
+		{text: `{{ default "Untitled" }}`, output: "Untitled"},
+		{text: `{{ default 1 2 3 }}`, err: "expected default and value found 3 values"},
+		{text: `{{ coalesce "" 0 nil "one" "two" }}`, output: "one"},
+		{text: `{{ coalesce "" 0 }}`, output: ""},
+		{text: `{{ ternary "on" "off" true }}`, output: "on"},
+		{text: `{{ .Active | ternary "on" "off" }}`, data: map[string]any{"Active": false}, output: "off"},
+		// first and last
+		{text: `{{ first (list 1 2 3) }} {{ last (list 1 2 3) }}`, output: "1 3"},
+		{text: `{{ first . }} {{ last . }}`, data: []string{"a", "b"}, output: "a b"},
+		{text: `{{ first (list) }}`, output: ""},
+		{text: `{{ last 1 }}`, err: "expected list found int"},
+		// seq
+		{text: `{{ seq 3 }}`, output: "[1 2 3]"},
+		{text: `{{ seq 2 4 }}`, output: "[2 3 4]"},
+		{text: `{{ seq 3 1 }}`, output: "[3 2 1]"},
+		{text: `{{ seq 0 5 10 }}`, output: "[0 5 10]"},
+		{text: `{{ seq 0 }}`, output: "[]"},
+		{text: `{{ seq 1 0 3 }}`, err: "increment must not be zero"},
+		{text: `{{ seq }}`, err: "expected 1 to 3 arguments found 0"},
+		{text: `{{ seq 0 1000000000000 }}`, err: "sequence exceeds the limit of 100000 values"},
+		{text: `{{ seq 0 9223372036854775807 }}`, err: "exceeds the limit"},
+		{text: `{{ seq -9223372036854775808 9223372036854775807 }}`, err: "exceeds the limit"},
+		{text: `{{ seq 9223372036854775800 5 9223372036854775807 }}`, output: "[9223372036854775800 9223372036854775805]"},
+		{text: `{{ seq -9223372036854775800 -9223372036854775808 -5 }}`, output: "[]"},
+		{text: `{{ seq 0 -9223372036854775808 -9223372036854775808 }}`, output: "[0 -9223372036854775808]"},
+	}
+
+	for _, test := range tests {
+		tp := template.Must(template.New("test").Funcs(funcMap).Parse(test.text))
+		var sb strings.Builder
+		err := tp.Execute(&sb, test.data)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected err: %q got: %v", test.text, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected err: %v", test.text, err)
+			continue
+		}
+		if sb.String() != test.output {
+			t.Errorf("%s: expected: %q got: %q", test.text, test.output, sb.String())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tmpl
+
+import (
+	"fmt"
+	"slices"
+	"text/template/parse"
+)
+
+// Collision records a template definition that overrides an autoloaded template definition with the same name.
+type Collision struct {
+	// Name is the name of the template defined more than once.
+	Name string
+
+	// File is the filepath of the file with the new definition.
+	File string
+
+	// PrevFile is the filepath of the autoloaded file with the overridden definition.
+	PrevFile string
+}
+
+func (c *Collision) Error() string {
+	return fmt.Sprintf("template %q defined in %s is already defined in %s", c.Name, c.File, c.PrevFile)
+}
+
+// definedTemplates returns the sorted names of the non-empty templates defined in the template text.
+// Empty definitions do not override existing templates and are not returned.
+//
+// Functions are not checked, text that fails to parse defines no templates
+// as the parse error is reported when the text is parsed into the template.
+func definedTemplates(name, text string) []string {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		return nil
+	}
+	var names []string
+	for name, tree := range trees {
+		if tree.Root != nil && !parse.IsEmptyTree(tree.Root) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// checkCollisions calls the collision handler for each template defined in the file text that is already autoloaded
+// from another file and returns the first error returned by the handler.
+// If autoload is true the definitions are recorded as autoloaded definitions.
+func (t *templatesParser) checkCollisions(file File, text string, autoload bool) error {
+	if t.onCollisionFn == nil {
+		return nil
+	}
+	names := definedTemplates(file.Name, text)
+	for _, name := range names {
+		if prev, ok := t.defined[name]; ok && prev != file.Path {
+			if err := t.onCollisionFn(&Collision{Name: name, File: file.Path, PrevFile: prev}); err != nil {
+				return err
+			}
+		}
+	}
+	if autoload {
+		for _, name := range names {
+			t.defined[name] = file.Path
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package tmpl
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOnCollision(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html": {Data: []byte(`<button>{{ . }}</button>`)},
+		"components/icon.html":   {Data: []byte(`{{ define "icon" }}<svg></svg>{{ end }}`)},
+		"icons/icon.html":        {Data: []byte(`{{ define "icon" }}<img>{{ end }}`)},
+		"pages/layout.html":      {Data: []byte(`{{ block "content" . }}{{ end }}`)},
+		"pages/index.html":       {Data: []byte(`{{ define "content" }}index{{ end }}{{ define "icon" }}{{ end }}`)},
+		"pages/about.html":       {Data: []byte(`{{ define "components/button" }}<a>{{ . }}</a>{{ end }}`)},
+	}
+
+	var collisions []Collision
+	_, err := New(fs).
+		OnCollision(func(c *Collision) error {
+			collisions = append(collisions, *c)
+			return nil
+		}).
+		Autoload("components", "icons").
+		LoadTree("pages").
+		Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[Collision]bool{
+		{Name: "icon", File: "icons/icon.html", PrevFile: "components/icon.html"}:                 true,
+		{Name: "components/button", File: "pages/about.html", PrevFile: "components/button.html"}: true,
+	}
+	if len(collisions) != len(expected) {
+		t.Errorf("expected: %v, got: %v", expected, collisions)
+	}
+	for _, c := range collisions {
+		if !expected[c] {
+			t.Errorf("unexpected collision: %v", c)
+		}
+	}
+
+	_, err = New(fs).
+		OnCollision(func(c *Collision) error { return c }).
+		Autoload("components").
+		LoadTree("pages").
+		Parse()
+	var collision *Collision
+	var loadErr *LoadError
+	if !errors.As(err, &collision) || !errors.As(err, &loadErr) || loadErr.File != "pages/about.html" {
+		t.Errorf("expected collision load error for pages/about.html, got: %v", err)
+	}
+	if expected := `pages/about.html: template "components/button" defined in pages/about.html is already defined in components/button.html`; err.Error() != expected {
+		t.Errorf("expected: %q, got: %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,191 @@
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"reflect"
+	"strings"
+)
+
+// component is a registered component.
+type component struct {
+	name     string
+	template string
+	props    reflect.Value // default props struct value, invalid if props are not typed
+}
+
+// components maps component names to registered components.
+type components map[string]*component
+
+// Component registers a component named name that renders the template with typed props.
+//
+// props is a struct or a pointer to a struct and it's field values are the default props.
+// Templates call the component with {{ component "name" (props "key" value ...) }} and an optional children argument.
+// Prop keys match the field name in the tmpl struct tag or the field name case insensitively.
+//
+// Props tagged `tmpl:",required"` must be set, props that do not match any field are errors.
+// If the props type has a Validate method, it is called before the component is rendered.
+// Children are passed to the Wrap method if the props type is a Layout, ie. embeds Children,
+// or set to a field named Children.
+//
+// A nil props renders the template with the props map as is.
+func (t *templatesParser) Component(name, template string, props any) *templatesParser {
+	c := &component{name: name, template: template}
+	if props != nil {
+		v := reflect.Indirect(reflect.ValueOf(props))
+		if v.Kind() != reflect.Struct {
+			t.addErr(fmt.Errorf("component %q: props must be a struct found %T", name, props))
+			return t
+		}
+		c.props = v
+	}
+	t.components[name] = c
+	return t
+}
+
+func componentFunc(t *template.Template, components components) any {
+	return func(name string, args ...any) (template.HTML, error) {
+		c, ok := components[name]
+		if !ok {
+			return "", fmt.Errorf("component %q is not registered", name)
+		}
+		if len(args) > 2 {
+			return "", fmt.Errorf("component %q: expected props and children found %d arguments", name, len(args))
+		}
+		var props map[string]any
+		var children any
+		if len(args) > 0 && args[0] != nil {
+			var ok bool
+			if props, ok = args[0].(map[string]any); !ok {
+				if m, isMap := args[0].(Map); isMap {
+					props, ok = m, true
+				}
+			}
+			if !ok {
+				return "", fmt.Errorf("component %q: expected props map found %T", name, args[0])
+			}
+		}
+		if len(args) > 1 {
+			children = args[1]
+		}
+		data, err := c.newProps(props, children)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		err = t.ExecuteTemplate(&buf, c.template, data)
+		return template.HTML(buf.String()), err
+	}
+}
+
+// newProps returns the props to render the component with.
+func (c *component) newProps(props map[string]any, children any) (any, error) {
+	if !c.props.IsValid() {
+		if children == nil {
+			return props, nil
+		}
+		m := make(map[string]any, len(props)+1)
+		for k, v := range props {
+			m[k] = v
+		}
+		m["children"] = children
+		return m, nil
+	}
+	ptr := reflect.New(c.props.Type())
+	v := ptr.Elem()
+	v.Set(c.props)
+	set := make(map[int]bool)
+	for key, value := range props {
+		i, ok := propField(v.Type(), key)
+		if !ok {
+			return nil, fmt.Errorf("component %q: unknown prop %q", c.name, key)
+		}
+		if err := setProp(v.Field(i), value); err != nil {
+			return nil, fmt.Errorf("component %q: prop %q %v", c.name, key, err)
+		}
+		set[i] = true
+	}
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if _, opts, _ := strings.Cut(field.Tag.Get("tmpl"), ","); opts == "required" && !set[i] {
+			return nil, fmt.Errorf("component %q: missing required prop %q", c.name, propName(field))
+		}
+	}
+	if children != nil {
+		if err := c.setChildren(ptr, children); err != nil {
+			return nil, err
+		}
+	}
+	data := ptr.Interface()
+	if validator, ok := data.(interface{ Validate() error }); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, fmt.Errorf("component %q: %w", c.name, err)
+		}
+	}
+	return v.Interface(), nil
+}
+
+// setChildren sets the children of the props pointed to by ptr.
+func (c *component) setChildren(ptr reflect.Value, children any) error {
+	if layout, ok := ptr.Interface().(Layout); ok {
+		tp, ok := children.(Template)
+		if !ok {
+			return fmt.Errorf("component %q: children must be a Template found %T", c.name, children)
+		}
+		layout.Wrap(tp)
+		return nil
+	}
+	field := ptr.Elem().FieldByName("Children")
+	if !field.IsValid() || !field.CanSet() {
+		return fmt.Errorf("component %q: does not accept children", c.name)
+	}
+	if err := setProp(field, children); err != nil {
+		return fmt.Errorf("component %q: children %v", c.name, err)
+	}
+	return nil
+}
+
+// propField returns the index of the exported field of the struct type that matches the prop key.
+func propField(typ reflect.Type, key string) (int, bool) {
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		if name, _, _ := strings.Cut(field.Tag.Get("tmpl"), ","); name == key || (name == "" && strings.EqualFold(field.Name, key)) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// propName returns the prop key of the struct field.
+func propName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("tmpl"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// setProp sets the field to value, converting between numeric types.
+func setProp(field reflect.Value, value any) error {
+	if value == nil {
+		field.SetZero()
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(field.Type()):
+		field.Set(v)
+	case isNumber(v.Kind()) && isNumber(field.Kind()):
+		field.Set(v.Convert(field.Type()))
+	default:
+		return fmt.Errorf("expects %s found %T", field.Type(), value)
+	}
+	return nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
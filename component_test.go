@@ -0,0 +1,110 @@
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type buttonProps struct {
+	Label    string `tmpl:"label,required"`
+	Variant  string
+	Disabled bool
+	Size     float64
+}
+
+func (p buttonProps) Validate() error {
+	if p.Variant != "primary" && p.Variant != "secondary" {
+		return errors.New("invalid variant " + p.Variant)
+	}
+	return nil
+}
+
+type cardProps struct {
+	Children
+	Title string
+}
+
+func TestComponent(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html": {Data: []byte(`<button class="{{ .Variant }}" data-size="{{ .Size }}"{{ if .Disabled }} disabled{{ end }}>{{ .Label }}</button>`)},
+		"components/card.html":   {Data: []byte(`<div><h2>{{ .Title }}</h2>{{ slot .Children }}</div>`)},
+		"components/badge.html":  {Data: []byte(`<span>{{ .text }}{{ slot .children }}</span>`)},
+	}
+	tests := []struct {
+		template string
+		expected string
+		err      string
+	}{
+		{
+			template: `{{ component "button" (props "label" "Save") }}`,
+			expected: `<button class="primary" data-size="1">Save</button>`,
+		},
+		{
+			template: `{{ component "button" (props "label" "Cancel" "variant" "secondary" "disabled" true "size" 2) }}`,
+			expected: `<button class="secondary" data-size="2" disabled>Cancel</button>`,
+		},
+		{
+			template: `{{ component "card" (props "title" "Card") (tmpl "body" "content") }}`,
+			expected: `<div><h2>Card</h2><p>content</p></div>`,
+		},
+		{
+			template: `{{ component "badge" (props "text" "New") "!" }}`,
+			expected: `<span>New!</span>`,
+		},
+		{
+			template: `{{ component "button" (props "variant" "secondary") }}`,
+			err:      `component "button": missing required prop "label"`,
+		},
+		{
+			template: `{{ component "button" (props "label" "x" "colour" "red") }}`,
+			err:      `component "button": unknown prop "colour"`,
+		},
+		{
+			template: `{{ component "button" (props "label" "x" "disabled" "yes") }}`,
+			err:      `component "button": prop "disabled" expects bool found string`,
+		},
+		{
+			template: `{{ component "button" (props "label" "x" "variant" "ghost") }}`,
+			err:      `component "button": invalid variant ghost`,
+		},
+		{
+			template: `{{ component "button" (props "label" "x") "children" }}`,
+			err:      `component "button": does not accept children`,
+		},
+		{
+			template: `{{ component "link" nil }}`,
+			err:      `component "link" is not registered`,
+		},
+	}
+	p := New(fs).
+		Component("button", "components/button", buttonProps{Variant: "primary", Size: 1}).
+		Component("card", "components/card", &cardProps{}).
+		Component("badge", "components/badge", nil).
+		Autoload("components")
+	for i, test := range tests {
+		name := fmt.Sprintf("test%d", i)
+		fs[name+".html"] = &fstest.MapFile{Data: []byte(`{{ define "body" }}<p>{{ . }}</p>{{ end }}` + test.template)}
+		p.Load(name)
+	}
+	templates := p.MustParse()
+	for i, test := range tests {
+		var buf bytes.Buffer
+		err := templates.Render(&buf, Tmpl(fmt.Sprintf("test%d", i), nil))
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error: %q, got: %v", test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("expected: %q, got: %q", test.expected, buf.String())
+		}
+	}
+}
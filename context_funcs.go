@@ -6,28 +6,77 @@ import (
 	"html/template"
 )
 
-func contextFuncMap(t *template.Template) template.FuncMap {
+func contextFuncMap(t *template.Template, components components) template.FuncMap {
 	return template.FuncMap{
-		"slot":   slotFunc(t),
-		"stream": streamFunc(t),
+		"slot":      slotFunc(t),
+		"slotNamed": slotNamedFunc(t),
+		"stream":    streamFunc(t),
+		"component": componentFunc(t, components),
+		"render":    renderFunc(t),
 	}
 }
 
+// slotFunc returns the slot func which renders slotted content.
+//
+//	slot content            renders content
+//	slot content fallback   renders fallback if content is empty
 func slotFunc(t *template.Template) any {
-	return func(data any) (any, error) {
-		if str, ok := data.(string); ok {
-			return str, nil
+	return func(content any, fallback ...any) (any, error) {
+		if len(fallback) > 1 {
+			return nil, fmt.Errorf("expected content and fallback content found %d arguments", len(fallback)+1)
 		}
-		if tp, ok := data.(Template); ok {
-			_, name, data := Info(tp)
-			var buf bytes.Buffer
-			err := t.ExecuteTemplate(&buf, name, data)
-			return template.HTML(buf.String()), err
+		if emptySlot(content) && len(fallback) == 1 {
+			return renderSlot(t, fallback[0])
 		}
-		return nil, fmt.Errorf("expected a valid slotted content got %T", data)
+		return renderSlot(t, content)
 	}
 }
 
+// slotNamedFunc returns the slotNamed func which renders named slotted content.
+//
+//	slotNamed "name" data            renders the slotted content named name in data
+//	slotNamed "name" data fallback   renders fallback if the slotted content is empty
+func slotNamedFunc(t *template.Template) any {
+	return func(name string, data any, fallback ...any) (any, error) {
+		if len(fallback) > 1 {
+			return nil, fmt.Errorf("slot %q: expected data and fallback content found %d arguments", name, len(fallback)+2)
+		}
+		content, ok := namedSlots(data, name)
+		if !ok {
+			return nil, fmt.Errorf("slot %q: expected data with named slots got %T", name, data)
+		}
+		if emptySlot(content) {
+			if len(fallback) == 1 {
+				return renderSlot(t, fallback[0])
+			}
+			// missing named slots render nothing
+			return "", nil
+		}
+		return renderSlot(t, content)
+	}
+}
+
+// renderSlot renders slotted content which can be a Template or string.
+// Empty Children render nothing.
+func renderSlot(t *template.Template, data any) (any, error) {
+	if str, ok := data.(string); ok {
+		return str, nil
+	}
+	if html, ok := data.(template.HTML); ok {
+		return html, nil
+	}
+	if data != nil && emptySlot(data) {
+		return "", nil
+	}
+	if tp, ok := data.(Template); ok {
+		_, name, data := Info(tp)
+		var buf bytes.Buffer
+		err := t.ExecuteTemplate(&buf, name, data)
+		return template.HTML(buf.String()), err
+	}
+	return nil, fmt.Errorf("expected a valid slotted content got %T", data)
+}
+
 func streamFunc(t *template.Template) any {
 	return func(name string, av asyncValuer) (template.HTML, error) {
 		return stream(t, name, av)
@@ -2,6 +2,8 @@ package tmpl
 
 import (
 	"bytes"
+	"html/template"
+	"strings"
 	"testing"
 	"testing/fstest"
 )
@@ -59,3 +61,82 @@ func TestSlot(t *testing.T) {
 		buf.Reset()
 	}
 }
+
+func TestNamedSlots(t *testing.T) {
+	type Page struct {
+		Children
+		Slots
+		Title string
+	}
+	fs := fstest.MapFS{
+		"layout.html": {
+			Data: []byte(`<head>{{ slotNamed "head" . "<title>Default</title>" }}</head>` +
+				`<aside>{{ slotNamed "sidebar" . }}</aside>` +
+				`<main>{{ slot .Children "Empty" }}</main>` +
+				`<footer>{{ slotNamed "footer" .Slots (tmpl "footer" .Title) }}</footer>` +
+				`{{ define "footer" }}<p>{{ . }}</p>{{ end }}` +
+				`{{ define "sidebar" }}<nav>{{ . }}</nav>{{ end }}` +
+				`{{ define "content" }}<h1>{{ . }}</h1>{{ end }}`),
+		},
+	}
+	buf := new(bytes.Buffer)
+	templates := New(fs).Load("layout").MustParse()
+	tests := []struct {
+		data     any
+		expected string
+	}{
+		{
+			data: Page{Title: "Home"},
+			expected: "<head>&lt;title&gt;Default&lt;/title&gt;</head><aside></aside>" +
+				"<main>Empty</main><footer><p>Home</p></footer>",
+		},
+		{
+			data: Page{
+				Children: Children{Tmpl("content", "Hello")},
+				Slots: Slots{
+					"head":    template.HTML("<title>Home</title>"),
+					"sidebar": Tmpl("sidebar", "links"),
+					"footer":  "Footer",
+				},
+			},
+			expected: "<head><title>Home</title></head><aside><nav>links</nav></aside>" +
+				"<main><h1>Hello</h1></main><footer>Footer</footer>",
+		},
+		{
+			data:     Map{"sidebar": "Map sidebar", "Slots": Slots{}, "Children": nil},
+			expected: "<head>&lt;title&gt;Default&lt;/title&gt;</head><aside>Map sidebar</aside><main>Empty</main><footer><p></p></footer>",
+		},
+	}
+	for _, test := range tests {
+		err := templates.Render(buf, Tmpl("layout", test.data))
+		if err != nil {
+			t.Error(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("expected: %q, got: %q", test.expected, buf.String())
+		}
+		buf.Reset()
+	}
+}
+
+func TestSlotNamedData(t *testing.T) {
+	fs := fstest.MapFS{
+		"layout.html": {Data: []byte(`{{ slot "text" }}|{{ slot "text" .missing }}|{{ slotNamed "sidebar" . }}`)},
+		"broken.html": {Data: []byte(`{{ slotNamed "sidebar" .title }}`)},
+	}
+	templates := New(fs).Load("layout").Load("broken").MustParse()
+	buf := new(bytes.Buffer)
+	// slot renders string content even when the dot has slots
+	err := templates.Render(buf, Tmpl("layout", Map{"sidebar": "Links", "text": "Slot"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "text|text|Links"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+
+	err = templates.Render(buf, Tmpl("broken", Map{"title": "Title"}))
+	if err == nil || !strings.Contains(err.Error(), `slot "sidebar": expected data with named slots got string`) {
+		t.Errorf("expected named slots error, got: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package tmpl
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+)
+
+// ErrNoTemplates is reported when loading a directory that contains no templates in strict mode.
+var ErrNoTemplates = errors.New("no templates found")
+
+// LoadError records a template file that failed to be read or parsed, or a directory that failed to be walked.
+type LoadError struct {
+	// Name is the template name, which is the filepath without the extension,
+	// or the directory when walking a directory failed.
+	Name string
+
+	// File is the filepath of the template file or directory.
+	File string
+
+	// Line is the line of the parse error or 0 if unknown.
+	Line int
+
+	// Err is the underlying read or parse error.
+	Err error
+}
+
+func (e *LoadError) Error() string {
+	var pathErr *fs.PathError
+	if e.Line == 0 && errors.As(e.Err, &pathErr) {
+		// path errors already include the filepath
+		return e.Err.Error()
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e *LoadError) Unwrap() error { return e.Err }
+
+// parseErrLine matches the line of a template or frontmatter parse error,
+// ie. "template: name:3: ..." or "frontmatter:3: ...".
+var parseErrLine = regexp.MustCompile(`^(?:template: .*?|frontmatter):(\d+):`)
+
+// newLoadError returns a LoadError for the template name loaded from file.
+func newLoadError(name, file string, err error) *LoadError {
+	le := &LoadError{Name: name, File: file, Err: err}
+	if m := parseErrLine.FindStringSubmatch(err.Error()); m != nil {
+		le.Line, _ = strconv.Atoi(m[1])
+	}
+	return le
+}
+
+// flattenErrors returns the errors joined in err.
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(e)...)
+	}
+	return errs
+}
@@ -0,0 +1,31 @@
+package tmpl
+
+// Extractor turns the content of a template file into template text.
+//
+// Extractors are registered for a file extension with SetExtractor
+// and preprocessors are registered for all files with Preprocess.
+type Extractor interface {
+	// Extract returns the template text from the content of the file.
+	Extract(file string, content []byte) ([]byte, error)
+}
+
+// ExtractorFunc is an adapter to allow the use of ordinary functions as extractors.
+type ExtractorFunc func(file string, content []byte) ([]byte, error)
+
+// Extract calls f(file, content).
+func (f ExtractorFunc) Extract(file string, content []byte) ([]byte, error) {
+	return f(file, content)
+}
+
+// GoExtractor extracts the template content defined with Define in single file go templates.
+// GoExtractor is registered for the "go" extension by default.
+var GoExtractor Extractor = ExtractorFunc(func(file string, content []byte) ([]byte, error) {
+	return []byte(extractGoFileContent(string(content))), nil
+})
+
+// defaultExtractors returns the extractors registered by default.
+func defaultExtractors() map[string]Extractor {
+	return map[string]Extractor{
+		"go": GoExtractor,
+	}
+}
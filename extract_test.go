@@ -0,0 +1,55 @@
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExtractors(t *testing.T) {
+	fs := fstest.MapFS{
+		"index.html": {
+			Data: []byte("<div>\n    {{ template \"intro\" . }}\n</div>"),
+		},
+		"intro.md": {
+			Data: []byte("# {{ . }}"),
+		},
+		"broken.md": {
+			Data: []byte(""),
+		},
+	}
+	markdown := ExtractorFunc(func(file string, content []byte) ([]byte, error) {
+		if len(content) == 0 {
+			return nil, errors.New("empty markdown")
+		}
+		return append([]byte("<h1>"), append(bytes.TrimPrefix(content, []byte("# ")), []byte("</h1>")...)...), nil
+	})
+	minify := ExtractorFunc(func(file string, content []byte) ([]byte, error) {
+		return regexp.MustCompile(`>\s+<`).ReplaceAll(bytes.TrimSpace(content), []byte("><")), nil
+	})
+	whitespace := ExtractorFunc(func(file string, content []byte) ([]byte, error) {
+		return regexp.MustCompile(`\n\s*`).ReplaceAll(content, nil), nil
+	})
+
+	templates := New(fs).
+		SetExt("html", "md").
+		SetExtractor("md", markdown).
+		Preprocess(whitespace, minify).
+		Load("intro", "index").
+		MustParse()
+	buf := new(bytes.Buffer)
+	if err := templates.Render(buf, Tmpl("index", "Hello")); err != nil {
+		t.Error(err)
+	}
+	if expected := "<div><h1>Hello</h1></div>"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+
+	_, err := New(fs).SetExt("md").SetExtractor("md", markdown).Load("broken").Parse()
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.File != "broken.md" {
+		t.Errorf("expected load error for broken.md got: %v", err)
+	}
+}
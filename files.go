@@ -1,6 +1,8 @@
 package tmpl
 
 import (
+	"errors"
+	"fmt"
 	"html/template"
 	"io/fs"
 	"path/filepath"
@@ -30,93 +32,283 @@ func extractGoFileContent(s string) string {
 	return ""
 }
 
-// parseFiles parses template files into t.
+// resolveFile returns the filepath and extension of the template file name
+// using the first extension in exts that the file exists with.
+// If the file does not exist with any extension, the filepath and extension of the first extension are returned.
+func resolveFile(fsys fs.FS, name string, exts []string) (string, string) {
+	for _, ext := range exts {
+		filename := withExt(name, ext)
+		if _, err := fs.Stat(fsys, filename); err == nil {
+			return filename, ext
+		}
+	}
+	if len(exts) == 0 {
+		return name, ""
+	}
+	return withExt(name, exts[0]), exts[0]
+}
+
+// withExt returns the filepath of the template file name with the file extension ext.
+func withExt(name, ext string) string {
+	if ext == "" {
+		return name
+	}
+	return name + "." + ext
+}
+
+// trimExt returns path without the first extension in exts that path has and the extension.
+// An empty extension matches any path.
+func trimExt(path string, exts []string) (string, string, bool) {
+	for _, ext := range exts {
+		if ext == "" {
+			return path, ext, true
+		}
+		if pathWithoutExt, ok := strings.CutSuffix(path, "."+ext); ok {
+			return pathWithoutExt, ext, true
+		}
+	}
+	return path, "", false
+}
+
+// reader reads template files from a filesystem.
+type reader struct {
+	fsys          fs.FS
+	exts          []string
+	extractors    map[string]Extractor
+	preprocessors []Extractor
+	frontmatter   []string
+	bundle        *Bundle
+
+	// stubFuncs adds functions that are not defined as stubs when parsing
+	stubFuncs bool
+}
+
+// read reads the template file name and returns the file and the template text.
+// The file extension is the first extension in exts that the file exists with,
+// the content is extracted using the extractor for the extension if any,
+// the frontmatter is stripped, block calls are expanded and then the content is preprocessed by each preprocessor.
 //
-// Repeated template names are overriden.
-func parseFiles(fsys fs.FS, t *template.Template, ext string, files []string) error {
-	for _, name := range files {
-		filename := name
-		if ext != "" {
-			filename = name + "." + ext
+// If the reader has a bundle, the file and the template text are read from the bundle instead.
+func (r reader) read(name string) (File, string, error) {
+	if r.bundle != nil {
+		bf, ok := r.bundle.Files[name]
+		if !ok {
+			return File{Name: name, Path: name}, "", &fs.PathError{Op: "bundle", Path: name, Err: fs.ErrNotExist}
 		}
-		b, err := fs.ReadFile(fsys, filename)
+		return File{Name: name, Path: bf.Path, Ext: bf.Ext, Layer: bf.Layer, Meta: bf.Meta}, bf.Text, nil
+	}
+	path, ext := resolveFile(r.fsys, name, r.exts)
+	file := File{Name: name, Path: path, Ext: ext}
+	if o, ok := r.fsys.(overlayFS); ok {
+		file.Layer = o.layer(path)
+	}
+	b, err := fs.ReadFile(r.fsys, path)
+	if err != nil {
+		return file, "", err
+	}
+	if extractor, ok := r.extractors[ext]; ok {
+		if b, err = extractor.Extract(path, b); err != nil {
+			return file, "", err
+		}
+	}
+	if slices.Contains(r.frontmatter, ext) {
+		meta, text, err := extractFrontmatter(string(b))
 		if err != nil {
-			return err
+			return file, "", err
 		}
-		tmpl := t.New(name)
-		text := string(b)
-		if ext == "go" {
-			text = extractGoFileContent(text)
+		file.Meta, b = meta, []byte(text)
+	}
+	for _, preprocessor := range r.preprocessors {
+		if b, err = preprocessor.Extract(path, b); err != nil {
+			return file, "", err
+		}
+	}
+	return file, string(b), nil
+}
+
+// parseFiles parses template files into t and returns the parsed files.
+//
+// Repeated template names are overriden, check is called with each file and it's text before it is parsed if not nil.
+// A file that fails to load does not stop the remaining files from being parsed,
+// the returned error joins a LoadError for each failed file.
+func parseFiles(r reader, t *template.Template, files []string, check func(File, string) error) ([]File, error) {
+	var parsed []File
+	var errs []error
+	for _, name := range files {
+		file, text, err := r.read(name)
+		if err == nil && check != nil {
+			err = check(file, text)
 		}
-		_, err = tmpl.Parse(text)
 		if err != nil {
+			errs = append(errs, newLoadError(name, file.Path, err))
+			continue
+		}
+		err = parseText(t, name, text, r.stubFuncs)
+		if err != nil {
+			errs = append(errs, newLoadError(name, file.Path, err))
+			continue
+		}
+		parsed = append(parsed, file)
+	}
+	return parsed, errors.Join(errs...)
+}
+
+// undefinedFunc matches the parse error of a call to a function that is not defined.
+var undefinedFunc = regexp.MustCompile(`function "([^"]+)" not defined`)
+
+// parseText parses text as the template name associated with t.
+// If stubFuncs is true, functions that are not defined are added to t as stubs that return an error when called.
+func parseText(t *template.Template, name, text string, stubFuncs bool) error {
+	var stubbed string
+	for {
+		_, err := t.New(name).Parse(text)
+		if err == nil || !stubFuncs {
+			return err
+		}
+		m := undefinedFunc.FindStringSubmatch(err.Error())
+		if m == nil || m[1] == stubbed {
 			return err
 		}
+		stubbed = m[1]
+		t.Funcs(template.FuncMap{stubbed: func(...any) (any, error) {
+			return nil, fmt.Errorf("function %q is not defined", m[1])
+		}})
 	}
-	return nil
 }
 
-// walkFiles walks dirs and returns a slice of filenames (without extension) that matches the file extension ext.
-func walkFiles(fsys fs.FS, ext string, dirs []string) []string {
+// walkFiles walks dirs and returns a slice of filenames (without extension) that matches any of the file extensions exts.
+// Files with the same name and different extensions are returned once.
+// The returned error joins the errors from walking each dir, including dirs that do not exist.
+func walkFiles(fsys fs.FS, exts []string, dirs []string) ([]string, error) {
 	var files []string
+	var errs []error
+	seen := make(map[string]struct{})
 	for _, dir := range dirs {
-		fs.WalkDir(fsys, dir, func(path string, d fs.DirEntry, err error) error {
+		err := fs.WalkDir(fsys, dir, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
 			if d.IsDir() {
 				return err
 			}
-			pathWithoutExt := strings.TrimSuffix(path, "."+ext)
-			// if pathWithoutExt remained unchanged then path does not have ext
-			if ext != "" && path == pathWithoutExt {
+			pathWithoutExt, _, ok := trimExt(path, exts)
+			if !ok {
 				return err
 			}
+			if _, ok := seen[pathWithoutExt]; ok {
+				return err
+			}
+			seen[pathWithoutExt] = struct{}{}
 			files = append(files, pathWithoutExt)
 			return err
 		})
+		if err != nil {
+			errs = append(errs, newLoadError(dir, dir, err))
+		}
 	}
-	return files
+	return files, errors.Join(errs...)
 }
 
-// walkFilesWithLayout walks a directory and for each filename that matches the file extension ext,
-// returns a slice of all layout filenames (without extension) in parent directories and the matched filename (without extension).
-func walkFilesWithLayout(fsys fs.FS, ext string, layoutFilename string, dir string) map[string][]string {
+// specialFilenames maps the filenames of special templates to the associated template name they are loaded as.
+var specialFilenames = map[string]string{
+	"error":     ErrorTemplate,
+	"loading":   LoadingTemplate,
+	"not-found": NotFoundTemplate,
+}
+
+// walkFilesWithLayout walks a directory and for each filename that matches any of the file extensions exts,
+// returns a slice of all layout filenames (without extension) in parent directories,
+// the nearest special filenames (without extension) and the matched filename (without extension).
+//
+// Special files are error, loading and not-found files, they are matched like any other file
+// and the nearest of each kind in the same or a parent directory is included before the matched filename.
+//
+// Group directories named (group) are left out of the returned names but not the filenames, except for special files.
+// Layout files named after the layout filename with an @ suffix reset layouts,
+// so layouts in parent directories are not included.
+//
+// Only dir and it's parent directories are walked, an error is returned if dir does not exist or cannot be walked.
+func walkFilesWithLayout(fsys fs.FS, exts []string, layoutFilename string, dir string) (map[string][]string, error) {
+	dir = strings.TrimSuffix(dir, "/")
+	if _, err := fs.Stat(fsys, dir); err != nil {
+		return nil, newLoadError(dir, dir, err)
+	}
 	groups := make(map[string][]string)
 	layouts := make([]string, 0)
-	fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+	specials := make([]string, 0)
+	var errs []error
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		if d.IsDir() {
+			// skip directories that are neither in dir nor a parent of dir
+			if !isWithin(path, dir) && !isWithin(dir, path) {
+				return fs.SkipDir
+			}
 			return err
 		}
-		pathWithoutExt := strings.TrimSuffix(path, "."+ext)
-		// if pathWithoutExt remained unchanged then path does not have ext
-		if ext != "" && path == pathWithoutExt {
+		pathWithoutExt, _, ok := trimExt(path, exts)
+		if !ok || slices.Contains(layouts, pathWithoutExt) || slices.Contains(specials, pathWithoutExt) {
 			return err
 		}
 		_, filename := filepath.Split(pathWithoutExt)
-		if filename == layoutFilename {
+		if filename == layoutFilename || filename == layoutFilename+"@" {
 			layouts = append(layouts, pathWithoutExt)
-		} else if dir == "." || strings.HasPrefix(pathWithoutExt, dir) {
-			groups[pathWithoutExt] = []string{pathWithoutExt}
+			return err
+		}
+		name := withoutGroups(pathWithoutExt)
+		if _, ok := specialFilenames[filename]; ok {
+			// special files are not named without groups as they are not pages
+			name = pathWithoutExt
+			specials = append(specials, pathWithoutExt)
+		}
+		if isWithin(pathWithoutExt, dir) {
+			if files, ok := groups[name]; ok {
+				if files[0] == pathWithoutExt {
+					// same file with another extension
+					return err
+				}
+				errs = append(errs, newLoadError(name, path, fmt.Errorf("template name conflicts with %s", files[0])))
+				return err
+			}
+			groups[name] = []string{pathWithoutExt}
 		}
 		return err
 	})
+	if err != nil {
+		return nil, newLoadError(dir, dir, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
 
-	if len(layouts) < 1 {
-		return groups
+	if len(layouts) < 1 && len(specials) < 1 {
+		return groups, nil
 	}
 	slices.SortFunc(layouts, func(a, b string) int {
-		return len(a) - len(b)
+		adir, _ := filepath.Split(a)
+		bdir, _ := filepath.Split(b)
+		return len(adir) - len(bdir)
+	})
+	// sort deeper special files first so the nearest file of each kind is found first
+	slices.SortFunc(specials, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
 	})
-	for name := range groups {
+	for name, group := range groups {
 		files := []string{}
-		fileDir, _ := filepath.Split(name)
+		file := group[0]
+		fileDir, _ := filepath.Split(file)
 		for _, layout := range layouts {
-			layoutDir, _ := filepath.Split(layout)
+			layoutDir, layoutFile := filepath.Split(layout)
 			if strings.HasPrefix(fileDir, layoutDir) {
+				// reset layouts in parent directories
+				if layoutFile == layoutFilename+"@" {
+					files = files[:0]
+				}
 				files = append(files, layout)
 			}
 			// no need to check deeper layout files
@@ -124,7 +316,37 @@ func walkFilesWithLayout(fsys fs.FS, ext string, layoutFilename string, dir stri
 				break
 			}
 		}
+		var nearest []string
+		seen := make(map[string]bool)
+		for _, special := range specials {
+			specialDir, filename := filepath.Split(special)
+			if seen[filename] || special == file || !strings.HasPrefix(fileDir, specialDir) {
+				continue
+			}
+			seen[filename] = true
+			nearest = append(nearest, special)
+		}
+		slices.SortFunc(nearest, func(a, b string) int {
+			_, af := filepath.Split(a)
+			_, bf := filepath.Split(b)
+			return strings.Compare(af, bf)
+		})
+		files = append(files, nearest...)
 		groups[name] = append(files, groups[name]...)
 	}
-	return groups
+	return groups, nil
+}
+
+// withoutGroups returns name without (group) path segments.
+func withoutGroups(name string) string {
+	segments := strings.Split(name, "/")
+	segments = slices.DeleteFunc(segments, func(s string) bool {
+		return len(s) > 2 && s[0] == '(' && s[len(s)-1] == ')'
+	})
+	return strings.Join(segments, "/")
+}
+
+// isWithin reports whether path is dir or a path inside dir.
+func isWithin(path, dir string) bool {
+	return dir == "." || path == dir || strings.HasPrefix(path, dir+"/")
 }
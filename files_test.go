@@ -2,7 +2,9 @@ package tmpl
 
 import (
 	"bytes"
+	"errors"
 	"html/template"
+	iofs "io/fs"
 	"maps"
 	"os"
 	"slices"
@@ -37,7 +39,10 @@ func TestWalkFiles(t *testing.T) {
 		"auth/login",
 		"auth/register",
 	}
-	got := walkFiles(fs, "html", []string{"."})
+	got, err := walkFiles(fs, []string{"html"}, []string{"."})
+	if err != nil {
+		t.Error(err)
+	}
 	slices.Sort(expected)
 	slices.Sort(got)
 	if !slices.Equal(expected, got) {
@@ -51,7 +56,29 @@ func TestWalkFiles(t *testing.T) {
 		"auth/login",
 		"auth/register",
 	}
-	got = walkFiles(fs, "html", []string{"auth", "app"})
+	got, err = walkFiles(fs, []string{"html"}, []string{"auth", "app"})
+	if err != nil {
+		t.Error(err)
+	}
+	slices.Sort(expected)
+	slices.Sort(got)
+	if !slices.Equal(expected, got) {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+
+	// walk multiple extensions
+	expected = []string{
+		"app/index",
+		"app/home",
+		"app/dashboard",
+		"auth/index",
+		"auth/login",
+		"auth/register",
+	}
+	got, err = walkFiles(fs, []string{"html", "tmpl"}, []string{"auth", "app"})
+	if err != nil {
+		t.Error(err)
+	}
 	slices.Sort(expected)
 	slices.Sort(got)
 	if !slices.Equal(expected, got) {
@@ -79,29 +106,47 @@ func TestWalkFilesWithLayout(t *testing.T) {
 		"auth/index":              {},
 		"auth/login.html":         {},
 		"auth/register.html":      {},
+		"application/index.html":  {},
+		"apple.html":              {},
+		"error.html":              {},
+		"app/account/error.html":  {},
+		"app/loading.html":        {},
 	}
 
 	// walk root
 	expected := map[string][]string{
-		"index":             {"layout", "index"},
-		"app/index":         {"layout", "app/layout", "app/index"},
-		"app/dashboard":     {"layout", "app/layout", "app/dashboard"},
-		"app/account/index": {"layout", "app/layout", "app/account/layout", "app/account/index"},
-		"auth/login":        {"layout", "auth/login"},
-		"auth/register":     {"layout", "auth/register"},
-	}
-	got := walkFilesWithLayout(fs, "html", "layout", ".")
+		"index":             {"layout", "error", "index"},
+		"app/index":         {"layout", "app/layout", "error", "app/loading", "app/index"},
+		"app/dashboard":     {"layout", "app/layout", "error", "app/loading", "app/dashboard"},
+		"app/account/index": {"layout", "app/layout", "app/account/layout", "app/account/error", "app/loading", "app/account/index"},
+		"auth/login":        {"layout", "error", "auth/login"},
+		"auth/register":     {"layout", "error", "auth/register"},
+		"application/index": {"layout", "error", "application/index"},
+		"apple":             {"layout", "error", "apple"},
+		"error":             {"layout", "error"},
+		"app/loading":       {"layout", "app/layout", "error", "app/loading"},
+		"app/account/error": {"layout", "app/layout", "app/account/layout", "error", "app/loading", "app/account/error"},
+	}
+	got, err := walkFilesWithLayout(fs, []string{"html"}, "layout", ".")
+	if err != nil {
+		t.Error(err)
+	}
 	if !maps.EqualFunc(expected, got, slices.Equal) {
 		t.Errorf("expected: %v, got: %v", expected, got)
 	}
 
 	// walk sub dir
 	expected = map[string][]string{
-		"app/index":         {"layout", "app/layout", "app/index"},
-		"app/dashboard":     {"layout", "app/layout", "app/dashboard"},
-		"app/account/index": {"layout", "app/layout", "app/account/layout", "app/account/index"},
+		"app/index":         {"layout", "app/layout", "error", "app/loading", "app/index"},
+		"app/dashboard":     {"layout", "app/layout", "error", "app/loading", "app/dashboard"},
+		"app/account/index": {"layout", "app/layout", "app/account/layout", "app/account/error", "app/loading", "app/account/index"},
+		"app/loading":       {"layout", "app/layout", "error", "app/loading"},
+		"app/account/error": {"layout", "app/layout", "app/account/layout", "error", "app/loading", "app/account/error"},
+	}
+	got, err = walkFilesWithLayout(fs, []string{"html"}, "layout", "app")
+	if err != nil {
+		t.Error(err)
 	}
-	got = walkFilesWithLayout(fs, "html", "layout", "app")
 	if !maps.EqualFunc(expected, got, slices.Equal) {
 		t.Errorf("expected: %v, got: %v", expected, got)
 	}
@@ -128,7 +173,7 @@ func TestGoTemplateFile(t *testing.T) {
 	}
 
 	tp := template.New("templates")
-	err := parseFiles(fs, tp, "go", files)
+	_, err := parseFiles(reader{fsys: fs, exts: []string{"go"}, extractors: defaultExtractors()}, tp, files, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -145,3 +190,104 @@ func TestGoTemplateFile(t *testing.T) {
 		buf.Reset()
 	}
 }
+
+func TestWalkErrors(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html": {},
+		"pages/index.html":       {},
+	}
+
+	_, err := walkFiles(fs, []string{"html"}, []string{"components", "componets"})
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Name != "componets" {
+		t.Errorf("expected load error for componets got: %v", err)
+	}
+
+	_, err = walkFilesWithLayout(fs, []string{"html"}, "layout", "page")
+	if !errors.As(err, &loadErr) || loadErr.Name != "page" {
+		t.Errorf("expected load error for page got: %v", err)
+	}
+
+	_, err = New(fs).Autoload("componets").Parse()
+	if !errors.Is(err, iofs.ErrNotExist) {
+		t.Errorf("expected not exist error got: %v", err)
+	}
+
+	// empty directories are only reported in strict mode
+	_, err = New(fs).Autoload("pages").LoadTree("components").Parse()
+	if err != nil {
+		t.Errorf("expected no error got: %v", err)
+	}
+	_, err = New(fs).Strict(true).SetExt("tmpl").LoadTree("components").Parse()
+	if !errors.Is(err, ErrNoTemplates) {
+		t.Errorf("expected no templates error got: %v", err)
+	}
+	_, err = New(fs).Strict(true).SetExt("tmpl").Autoload("components").Parse()
+	if !errors.Is(err, ErrNoTemplates) {
+		t.Errorf("expected no templates error got: %v", err)
+	}
+}
+
+func TestWalkFilesWithGroups(t *testing.T) {
+	fs := fstest.MapFS{
+		"layout.html":                {},
+		"index.html":                 {},
+		"(shop)/layout.html":         {},
+		"(shop)/cart/index.html":     {},
+		"(shop)/error.html":          {},
+		"(auth)/layout@.html":        {},
+		"(auth)/login.html":          {},
+		"(auth)/account/layout.html": {},
+		"(auth)/account/index.html":  {},
+	}
+
+	expected := map[string][]string{
+		"index":         {"layout", "index"},
+		"cart/index":    {"layout", "(shop)/layout", "(shop)/error", "(shop)/cart/index"},
+		"(shop)/error":  {"layout", "(shop)/layout", "(shop)/error"},
+		"login":         {"(auth)/layout@", "(auth)/login"},
+		"account/index": {"(auth)/layout@", "(auth)/account/layout", "(auth)/account/index"},
+	}
+	got, err := walkFilesWithLayout(fs, []string{"html"}, "layout", ".")
+	if err != nil {
+		t.Error(err)
+	}
+	if !maps.EqualFunc(expected, got, slices.Equal) {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+
+	// conflicting names in groups
+	fs["(shop)/login.html"] = &fstest.MapFile{}
+	_, err = walkFilesWithLayout(fs, []string{"html"}, "layout", ".")
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Name != "login" {
+		t.Errorf("expected load error for login got: %v", err)
+	}
+}
+
+func TestParseFilesWithExts(t *testing.T) {
+	fs := fstest.MapFS{
+		"page.html": {
+			Data: []byte(`<p>{{ template "icon" }}{{ template "button" }}</p>`),
+		},
+		"icon.svg": {
+			Data: []byte(`<svg></svg>`),
+		},
+		"button.go": {
+			Data: []byte("package components\n\nfunc init() {\n\ttmpl.Define(`<button></button>`)\n}"),
+		},
+	}
+	tp := template.New("templates")
+	r := reader{fsys: fs, exts: []string{"html", "svg", "go"}, extractors: defaultExtractors()}
+	_, err := parseFiles(r, tp, []string{"icon", "button", "page"}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := tp.ExecuteTemplate(buf, "page", nil); err != nil {
+		t.Error(err)
+	}
+	if expected := "<p><svg></svg><button></button></p>"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,336 @@
+package tmpl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// extractFrontmatter extracts YAML frontmatter delimited by "---" lines
+// or TOML frontmatter delimited by "+++" lines at the start of text.
+//
+// The frontmatter is replaced with the same number of newlines,
+// so line numbers in template errors remain correct.
+// If text has no frontmatter, text is returned unchanged with a nil map.
+func extractFrontmatter(text string) (map[string]any, string, error) {
+	var delim string
+	switch {
+	case strings.HasPrefix(text, "---\n"), strings.HasPrefix(text, "---\r\n"):
+		delim = "---"
+	case strings.HasPrefix(text, "+++\n"), strings.HasPrefix(text, "+++\r\n"):
+		delim = "+++"
+	default:
+		return nil, text, nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], "\r\n") == delim {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, text, fmt.Errorf("frontmatter:1: missing closing %q", delim)
+	}
+	var meta map[string]any
+	var err error
+	if delim == "---" {
+		meta, err = parseYAMLFrontmatter(lines[1:end])
+	} else {
+		meta, err = parseTOMLFrontmatter(lines[1:end])
+	}
+	if err != nil {
+		return nil, text, err
+	}
+	body := strings.Join(lines[end+1:], "")
+	// preserve line numbers with newlines in place of the frontmatter lines
+	newlines := end
+	if strings.HasSuffix(lines[end], "\n") {
+		newlines++
+	}
+	return meta, strings.Repeat("\n", newlines) + body, nil
+}
+
+// frontmatterLine is a non-empty frontmatter line with it's indentation.
+type frontmatterLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// parseYAMLFrontmatter parses a subset of YAML consisting of
+// scalars, inline lists, block lists of scalars and nested maps.
+func parseYAMLFrontmatter(rawLines []string) (map[string]any, error) {
+	var lines []frontmatterLine
+	for i, line := range rawLines {
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("frontmatter:%d: tabs are not allowed for indentation", i+2)
+		}
+		lines = append(lines, frontmatterLine{i + 2, len(line) - len(trimmed), trimmed})
+	}
+	meta, rest, err := parseYAMLMap(lines, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("frontmatter:%d: unexpected indentation", rest[0].num)
+	}
+	return meta, nil
+}
+
+// parseYAMLMap parses map entries with the given indentation and returns the remaining lines.
+func parseYAMLMap(lines []frontmatterLine, indent int) (map[string]any, []frontmatterLine, error) {
+	m := make(map[string]any)
+	for len(lines) > 0 && lines[0].indent == indent {
+		line := lines[0]
+		key, value, ok := strings.Cut(line.text, ":")
+		if !ok || strings.HasPrefix(line.text, "- ") {
+			return nil, nil, fmt.Errorf("frontmatter:%d: expected key: value", line.num)
+		}
+		key, value = unquote(strings.TrimSpace(key)), stripComment(strings.TrimSpace(value))
+		lines = lines[1:]
+		if value != "" {
+			v, err := parseScalar(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("frontmatter:%d: %w", line.num, err)
+			}
+			m[key] = v
+			continue
+		}
+		// nested block or null value
+		if len(lines) == 0 || lines[0].indent < indent ||
+			(lines[0].indent == indent && !strings.HasPrefix(lines[0].text, "- ")) {
+			m[key] = nil
+			continue
+		}
+		if strings.HasPrefix(lines[0].text, "- ") || lines[0].text == "-" {
+			list, rest, err := parseYAMLList(lines, lines[0].indent)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[key], lines = list, rest
+			continue
+		}
+		nested, rest, err := parseYAMLMap(lines, lines[0].indent)
+		if err != nil {
+			return nil, nil, err
+		}
+		m[key], lines = nested, rest
+	}
+	if len(lines) > 0 && lines[0].indent > indent {
+		return nil, nil, fmt.Errorf("frontmatter:%d: unexpected indentation", lines[0].num)
+	}
+	return m, lines, nil
+}
+
+// parseYAMLList parses list items of scalars with the given indentation and returns the remaining lines.
+func parseYAMLList(lines []frontmatterLine, indent int) ([]any, []frontmatterLine, error) {
+	var list []any
+	for len(lines) > 0 && lines[0].indent == indent && (strings.HasPrefix(lines[0].text, "- ") || lines[0].text == "-") {
+		line := lines[0]
+		v, err := parseScalar(strings.TrimSpace(strings.TrimPrefix(line.text, "-")))
+		if err != nil {
+			return nil, nil, fmt.Errorf("frontmatter:%d: %w", line.num, err)
+		}
+		list = append(list, v)
+		lines = lines[1:]
+	}
+	return list, lines, nil
+}
+
+// parseTOMLFrontmatter parses a subset of TOML consisting of
+// key = value pairs with scalars and inline arrays, and [table] headers.
+func parseTOMLFrontmatter(rawLines []string) (map[string]any, error) {
+	meta := make(map[string]any)
+	table := meta
+	for i, line := range rawLines {
+		num := i + 2
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			table = meta
+			for _, key := range strings.Split(strings.Trim(line, "[]"), ".") {
+				key = unquote(strings.TrimSpace(key))
+				next, ok := table[key].(map[string]any)
+				if !ok {
+					if _, exists := table[key]; exists {
+						return nil, fmt.Errorf("frontmatter:%d: key %q is not a table", num, key)
+					}
+					next = make(map[string]any)
+					table[key] = next
+				}
+				table = next
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("frontmatter:%d: expected key = value", num)
+		}
+		v, err := parseScalar(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("frontmatter:%d: %w", num, err)
+		}
+		table[unquote(strings.TrimSpace(key))] = v
+	}
+	return meta, nil
+}
+
+// parseScalar parses a quoted string, bool, null, decimal integer, decimal float, inline list or plain string.
+// A trailing comment is stripped before the value is parsed.
+func parseScalar(s string) (any, error) {
+	s = stripComment(s)
+	switch {
+	case s == "" || s == "~" || s == "null":
+		return nil, nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("invalid string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("invalid list %s", s)
+		}
+		var list []any
+		for _, item := range splitList(s[1 : len(s)-1]) {
+			v, err := parseScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	if !decimalNumber.MatchString(s) {
+		return s, nil
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// decimalNumber matches decimal integer and float literals,
+// other forms like nan, inf, hex or digits separated by underscores are strings.
+var decimalNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// stripComment returns s without a trailing comment,
+// a comment starts with # at the start of s or after whitespace outside of quoted strings.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[,", s[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
+
+// splitList splits the items of an inline list by commas outside of quotes and brackets.
+func splitList(s string) []string {
+	var items []string
+	var quote rune
+	depth, start := 0, 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
+
+// unquote removes quotes from a quoted key.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// mergeMeta returns a new map with the entries of all maps, later maps override earlier maps.
+func mergeMeta(metas ...map[string]any) map[string]any {
+	var merged map[string]any
+	for _, meta := range metas {
+		if meta == nil {
+			continue
+		}
+		if merged == nil {
+			merged = make(map[string]any)
+		}
+		for k, v := range meta {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// metaFunc returns the meta template func for the frontmatter meta.
+//
+// meta returns the whole frontmatter without arguments or the value at the dotted key path.
+func metaFunc(meta map[string]any) func(keys ...string) (any, error) {
+	return func(keys ...string) (any, error) {
+		if len(keys) == 0 {
+			return meta, nil
+		}
+		if len(keys) > 1 {
+			return nil, fmt.Errorf("expected at most one key found %d", len(keys))
+		}
+		var value any = meta
+		for _, key := range strings.Split(keys[0], ".") {
+			m, ok := value.(map[string]any)
+			if !ok {
+				return nil, nil
+			}
+			value = m[key]
+		}
+		return value, nil
+	}
+}
@@ -0,0 +1,168 @@
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFrontmatter(t *testing.T) {
+	tests := []struct {
+		input  string
+		meta   map[string]any
+		output string
+		err    string
+	}{
+		{
+			input:  "<p>No frontmatter</p>",
+			meta:   nil,
+			output: "<p>No frontmatter</p>",
+		},
+		{
+			input: "---\ntitle: Home\ncount: 3\nratio: 0.5\npublic: true\nempty:\nquoted: \"a: b\"\n---\n<p></p>",
+			meta: map[string]any{
+				"title":  "Home",
+				"count":  3,
+				"ratio":  0.5,
+				"public": true,
+				"empty":  nil,
+				"quoted": "a: b",
+			},
+			output: "\n\n\n\n\n\n\n\n<p></p>",
+		},
+		{
+			input: "---\n# comment\ntags: [a, 'b c']\nroles:\n  - admin\n  - editor\ncache:\n  policy: public\n  max_age: 60\n---\n",
+			meta: map[string]any{
+				"tags":  []any{"a", "b c"},
+				"roles": []any{"admin", "editor"},
+				"cache": map[string]any{"policy": "public", "max_age": 60},
+			},
+			output: "\n\n\n\n\n\n\n\n\n\n",
+		},
+		{
+			input: "+++\ntitle = \"Home\"\ntags = [\"a\", \"b\"]\n[cache]\npolicy = 'private'\n+++\n<p></p>",
+			meta: map[string]any{
+				"title": "Home",
+				"tags":  []any{"a", "b"},
+				"cache": map[string]any{"policy": "private"},
+			},
+			output: "\n\n\n\n\n\n<p></p>",
+		},
+		{
+			input: "---\ncount: 5 # items\nratio: 0.5 # half\ntitle: \"a\" # c\nname: 'it''s' # c\ncolor: \"#fff\"\nurl: a#b\ntags: [a, \"#b\"] # c\nnone: # c\ncache: # settings\n  policy: public # c\nlist:\n  - 1 # one\n---\n",
+			meta: map[string]any{
+				"count": 5,
+				"ratio": 0.5,
+				"title": "a",
+				"name":  "it's",
+				"color": "#fff",
+				"url":   "a#b",
+				"tags":  []any{"a", "#b"},
+				"none":  nil,
+				"cache": map[string]any{"policy": "public"},
+				"list":  []any{1},
+			},
+			output: "\n\n\n\n\n\n\n\n\n\n\n\n\n\n",
+		},
+		{
+			input:  "+++\ncount = 5 # x\ntitle = \"a # b\" # c\n+++\n",
+			meta:   map[string]any{"count": 5, "title": "a # b"},
+			output: "\n\n\n\n",
+		},
+		{
+			input: "---\nn: nan\ni: inf\nj: -Infinity\nu: 1_000\nh: 0x10\nf: 1e3\ng: -.5\nk: +7\n---\n",
+			meta: map[string]any{
+				"n": "nan",
+				"i": "inf",
+				"j": "-Infinity",
+				"u": "1_000",
+				"h": "0x10",
+				"f": 1000.0,
+				"g": -0.5,
+				"k": 7,
+			},
+			output: "\n\n\n\n\n\n\n\n\n\n",
+		},
+		{
+			input:  "+++\nn = nan\ni = inf\nu = 1_000\n+++\n",
+			meta:   map[string]any{"n": "nan", "i": "inf", "u": "1_000"},
+			output: "\n\n\n\n\n",
+		},
+		{
+			input: "---\ntitle: Home\n",
+			err:   `frontmatter:1: missing closing "---"`,
+		},
+		{
+			input: "---\ntitle: Home\n  nested: value\n---\n",
+			err:   "frontmatter:3: unexpected indentation",
+		},
+		{
+			input: "+++\ntitle\n+++\n",
+			err:   "frontmatter:2: expected key = value",
+		},
+	}
+
+	for _, test := range tests {
+		meta, output, err := extractFrontmatter(test.input)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("expected err: %q got: %q", test.err, err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("expected err: %q got: %v", test.err, err)
+		}
+		if !reflect.DeepEqual(meta, test.meta) {
+			t.Errorf("expected: %v got: %v", test.meta, meta)
+		}
+		if output != test.output {
+			t.Errorf("expected: %q got: %q", test.output, output)
+		}
+	}
+}
+
+func TestMetaFunc(t *testing.T) {
+	fs := fstest.MapFS{
+		"layout.html": {
+			Data: []byte("---\ntitle: Site\ndescription: Default\n---\n<title>{{ meta \"title\" }}</title><meta content=\"{{ meta \"description\" }}\">{{ slot .Children }}"),
+		},
+		"index.html": {
+			Data: []byte("---\ntitle: Home\nauth:\n  role: admin\n---\n<p>{{ meta \"auth.role\" }}</p>\n"),
+		},
+	}
+	templates := New(fs).Frontmatter("html").LoadTree(".").Load("layout", "index").MustParse()
+
+	buf := new(bytes.Buffer)
+	err := templates.Render(buf, Associated("index", "layout", Map{"Children": Tmpl("index", Map{})}))
+	if err != nil {
+		t.Error(err)
+	}
+	if expected := "\n\n\n\n" + `<title>Home</title><meta content="Default">` + "\n\n\n\n\n" + `<p>admin</p>` + "\n"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+
+	meta, _ := templates.Metadata("index")
+	if meta.Meta["title"] != "Home" || meta.Files[0].Meta["title"] != "Site" {
+		t.Errorf("unexpected metadata: %v", meta.Meta)
+	}
+
+	fs["broken.html"] = &fstest.MapFile{Data: []byte("---\ntitle: [a\n---\n")}
+	_, err = New(fs).Frontmatter("html").Load("broken").Parse()
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Line != 2 {
+		t.Errorf("expected load error on line 2 got: %v", err)
+	}
+
+	// frontmatter is opt-in so text that starts with a fence is template text
+	fs["rule.html"] = &fstest.MapFile{Data: []byte("---\n<p>{{ . }}</p>")}
+	buf.Reset()
+	if err := New(fs).Load("rule").MustParse().Render(buf, Tmpl("rule", "x")); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "---\n<p>x</p>"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+}
@@ -3,12 +3,37 @@ package tmpl
 import (
 	"fmt"
 	"html/template"
+	"maps"
+	"slices"
+	"strings"
 )
 
 var funcMap = template.FuncMap{
-	"tmpl": Tmpl,
-	"map":  mapFunc,
-	"clsx": clsxFunc,
+	"tmpl":    Tmpl,
+	"map":     mapFunc,
+	"props":   mapFunc,
+	"clsx":    clsxFunc,
+	"attrs":   attrsFunc,
+	"twMerge": twMergeFunc,
+	"meta":    metaFunc(nil),
+	"ctx":     ctxFunc(nil),
+
+	"list":     listFunc,
+	"append":   appendFunc,
+	"merge":    mergeFunc,
+	"pick":     pickFunc,
+	"omit":     omitFunc,
+	"set":      setFunc,
+	"default":  defaultFunc,
+	"coalesce": coalesceFunc,
+	"ternary":  ternaryFunc,
+	"first":    firstFunc,
+	"last":     lastFunc,
+	"seq":      seqFunc,
+
+	"json":       jsonFunc,
+	"jsonScript": jsonScriptFunc,
+	"hydrate":    hydrateFunc,
 }
 
 func mapFunc(v ...any) (map[string]any, error) {
@@ -31,6 +56,9 @@ func clsxFunc(values ...any) (string, error) {
 	var result string
 	var matching, cond bool
 	appendStr := func(s string) {
+		if s == "" {
+			return
+		}
 		if result == "" {
 			result = s
 		} else {
@@ -46,27 +74,54 @@ func clsxFunc(values ...any) (string, error) {
 			matching, cond = true, v
 			continue
 		}
-		if v, ok := value.(string); ok {
-			if matching {
-				if cond {
-					appendStr(v)
-				}
-				// reset matching
-				matching, cond = false, false
-			} else {
-				appendStr(v)
-			}
-			continue
-		}
 		if value == nil {
 			// reset matching
 			matching, cond = false, false
 			continue
 		}
-		return "", fmt.Errorf("value must be string or bool")
+		v, err := clsxValue(value)
+		if err != nil {
+			return "", err
+		}
+		if !matching || cond {
+			appendStr(v)
+		}
+		// reset matching
+		matching, cond = false, false
 	}
 	if matching {
 		return "", fmt.Errorf("expected a string after match condition")
 	}
 	return result, nil
 }
+
+// clsxValue returns the classes of a string, a map of classes to conditions or a slice of clsx values.
+func clsxValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []string:
+		return strings.Join(v, " "), nil
+	case []any:
+		return clsxFunc(v...)
+	case map[string]bool:
+		var classes []string
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			if v[k] {
+				classes = append(classes, k)
+			}
+		}
+		return strings.Join(classes, " "), nil
+	case map[string]any:
+		var classes []string
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			if truthy, _ := template.IsTrue(v[k]); truthy {
+				classes = append(classes, k)
+			}
+		}
+		return strings.Join(classes, " "), nil
+	case Map:
+		return clsxValue(map[string]any(v))
+	}
+	return "", fmt.Errorf("value must be string, bool, nil, slice or map found %T", value)
+}
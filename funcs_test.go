@@ -1,8 +1,11 @@
 package tmpl
 
 import (
+	"bytes"
+	"html/template"
 	"maps"
 	"testing"
+	"testing/fstest"
 )
 
 func TestMap(t *testing.T) {
@@ -84,7 +87,7 @@ func TestClsx(t *testing.T) {
 		{
 			input:  []any{"one", "two", "three", 1},
 			output: "",
-			err:    "value must be string or bool",
+			err:    "value must be string, bool, nil, slice or map found int",
 		},
 		{
 			input:  []any{"one", "two", nil, "three"},
@@ -121,3 +124,139 @@ func TestClsx(t *testing.T) {
 		}
 	}
 }
+
+func TestAttrs(t *testing.T) {
+	tests := []struct {
+		input  []any
+		output template.HTMLAttr
+		err    string
+	}{
+		{
+			input:  []any{"type", "button", "disabled", true, "hidden", false, "title", nil},
+			output: `type="button" disabled`,
+		},
+		{
+			input:  []any{"class", "btn px-2", "id", "a", map[string]any{"class": "active", "id": "b", "data-x": `"1"`}},
+			output: `class="btn px-2 active" id="b" data-x="&#34;1&#34;"`,
+		},
+		{
+			// spread attributes built by attrs and remove attributes set by earlier values
+			input:  []any{"disabled", true, "class", "btn", template.HTMLAttr(`data-theme-toggle class="wide" disabled`), Map{"disabled": false}},
+			output: `class="btn wide" data-theme-toggle`,
+		},
+		{
+			input:  []any{"style", "color: red", "style", "margin: 0"},
+			output: `style="color: red; margin: 0"`,
+		},
+		{
+			input:  []any{"href", "javascript:alert(1)", "src", "/img.png", "style", "background: url(x)"},
+			output: `href="#ZgotmplZ" src="/img.png" style="ZgotmplZ"`,
+		},
+		{
+			input:  []any{"onclick", template.JS("toggle()")},
+			output: `onclick="toggle()"`,
+		},
+		{
+			input:  []any{template.HTMLAttr(`onclick="toggle()"`), "type", "button"},
+			output: `onclick="toggle()" type="button"`,
+		},
+		{
+			// spread unquoted and single quoted values
+			input:  []any{template.HTMLAttr(`x=y z data-a='b c' data-b = "d"`)},
+			output: `x="y" z data-a="b c" data-b="d"`,
+		},
+		{
+			input: []any{template.HTMLAttr(`data-a="b`)},
+			err:   "cannot spread attribute data-a with an unterminated value",
+		},
+		{
+			input: []any{template.HTMLAttr(`data-a=b"c`)},
+			err:   `cannot spread attribute data-a with unquoted value "b\"c"`,
+		},
+		{
+			input: []any{template.HTMLAttr(`data-a="b"c`)},
+			err:   "cannot spread attribute data-a, expected whitespace after the value",
+		},
+		{
+			input: []any{template.HTMLAttr(`"x"`)},
+			err:   `cannot spread attribute "\"x\""`,
+		},
+		{
+			input: []any{"onclick", "alert(1)"},
+			err:   "attribute onclick expects template.JS found string",
+		},
+		{
+			input: []any{`x" onload="alert(1)`, "y"},
+			err:   `invalid attribute name "x\" onload=\"alert(1)"`,
+		},
+		{
+			input: []any{"type"},
+			err:   "key type missing value",
+		},
+		{
+			input: []any{1, "a"},
+			err:   "expected string key or attributes found int",
+		},
+	}
+	for _, test := range tests {
+		output, err := attrsFunc(test.input...)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("expected err: %q got: %q", test.err, err)
+			}
+		} else if test.err != "" {
+			t.Errorf("expected err: %q got: %v", test.err, err)
+		}
+		if output != test.output {
+			t.Errorf("expected: %q got: %q", test.output, output)
+		}
+	}
+}
+
+func TestAttrsSpread(t *testing.T) {
+	fs := fstest.MapFS{
+		"button.html": {Data: []byte(`<button {{ attrs "type" "button" "class" "btn" .attrs }}>{{ .label }}</button>`)},
+		"index.html":  {Data: []byte(`{{ template "button" map "label" "Toggle" "attrs" (attrs "data-theme-toggle" true "class" "wide" "type" "submit") }}`)},
+	}
+	templates := New(fs).Load("button", "index").MustParse()
+	buf := new(bytes.Buffer)
+	if err := templates.Render(buf, Tmpl("index", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `<button type="submit" class="btn wide" data-theme-toggle>Toggle</button>`; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+}
+
+func TestTwMerge(t *testing.T) {
+	tests := []struct {
+		input  []any
+		output string
+	}{
+		{[]any{"p-2 bg-red-500", "p-4"}, "bg-red-500 p-4"},
+		{[]any{"px-2 py-1 p-3"}, "p-3"},
+		{[]any{"p-3 px-2"}, "p-3 px-2"},
+		{[]any{"hover:bg-red-500 bg-white hover:bg-blue-500"}, "bg-white hover:bg-blue-500"},
+		{[]any{"md:hover:p-2 hover:md:p-4"}, "hover:md:p-4"},
+		{[]any{"text-sm text-red-500 text-lg text-center"}, "text-red-500 text-lg text-center"},
+		{[]any{"border border-2 border-red-500 border-dashed border-t-4"}, "border-2 border-red-500 border-dashed border-t-4"},
+		{[]any{"font-bold font-mono font-medium"}, "font-mono font-medium"},
+		{[]any{"block flex hidden md:flex"}, "hidden md:flex"},
+		{[]any{"w-4 h-4 size-8"}, "size-8"},
+		{[]any{"shadow shadow-lg shadow-red-500"}, "shadow-lg shadow-red-500"},
+		{[]any{"bg-[#fff] bg-[url(/a.png)] bg-black"}, "bg-[url(/a.png)] bg-black"},
+		{[]any{"-mt-2 mt-4 !mt-1 !mt-2"}, "mt-4 !mt-2"},
+		{[]any{"custom p-2 custom"}, "custom p-2 custom"},
+		{[]any{"p-2", map[string]bool{"p-4": true, "m-2": false}, []string{"rounded", "rounded-lg"}}, "p-4 rounded-lg"},
+		{[]any{"p-2", false, "p-4", []any{true, "m-1", "m-2"}}, "p-2 m-2"},
+	}
+	for _, test := range tests {
+		output, err := twMergeFunc(test.input...)
+		if err != nil {
+			t.Error(err)
+		}
+		if output != test.output {
+			t.Errorf("%v: expected: %q got: %q", test.input, test.output, output)
+		}
+	}
+}
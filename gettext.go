@@ -0,0 +1,295 @@
+package tmpl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// poEntry is a message of a gettext PO file.
+type poEntry struct {
+	ctxt, id, plural string
+	strs             []string
+	fuzzy            bool
+	line             int
+}
+
+// parsePO parses the messages of a gettext PO file and the plural form func from the Plural-Forms header.
+// Fuzzy and untranslated messages and messages with a context are skipped.
+// A parse error reports the line of the error.
+func parsePO(text string) (map[string]message, func(int) int, int, error) {
+	var entries []*poEntry
+	var entry *poEntry
+	var appendStr func(string)
+	fuzzy := false
+	for i, line := range strings.Split(text, "\n") {
+		lineNum := i + 1
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			appendStr = nil
+			continue
+		case strings.HasPrefix(line, "#"):
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				fuzzy = true
+			}
+			continue
+		case strings.HasPrefix(line, `"`):
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, nil, lineNum, fmt.Errorf("invalid string %s", line)
+			}
+			if appendStr == nil {
+				return nil, nil, lineNum, fmt.Errorf("unexpected string %s", line)
+			}
+			appendStr(s)
+			continue
+		}
+
+		keyword, value, _ := strings.Cut(line, " ")
+		s, err := strconv.Unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, nil, lineNum, fmt.Errorf("invalid string %s", strings.TrimSpace(value))
+		}
+		// msgctxt or a msgid after msgstr starts a new entry
+		if keyword == "msgctxt" || (keyword == "msgid" && (entry == nil || entry.strs != nil)) {
+			entry = &poEntry{fuzzy: fuzzy, line: lineNum}
+			entries = append(entries, entry)
+			fuzzy = false
+		}
+		if entry == nil {
+			return nil, nil, lineNum, fmt.Errorf("expected msgid found %s", keyword)
+		}
+		e := entry
+		switch {
+		case keyword == "msgctxt":
+			e.ctxt = s
+			appendStr = func(s string) { e.ctxt += s }
+		case keyword == "msgid":
+			e.id = s
+			appendStr = func(s string) { e.id += s }
+		case keyword == "msgid_plural":
+			e.plural = s
+			appendStr = func(s string) { e.plural += s }
+		case keyword == "msgstr" || strings.HasPrefix(keyword, "msgstr["):
+			n := 0
+			if keyword != "msgstr" {
+				index, ok := strings.CutSuffix(keyword[len("msgstr["):], "]")
+				if n, err = strconv.Atoi(index); !ok || err != nil || n < 0 {
+					return nil, nil, lineNum, fmt.Errorf("invalid keyword %s", keyword)
+				}
+			}
+			if n >= len(e.strs) {
+				e.strs = append(e.strs, make([]string, n+1-len(e.strs))...)
+			}
+			e.strs[n] = s
+			appendStr = func(s string) { e.strs[n] += s }
+		default:
+			return nil, nil, lineNum, fmt.Errorf("unknown keyword %s", keyword)
+		}
+	}
+
+	messages := make(map[string]message)
+	plural := func(n int) int {
+		if n != 1 {
+			return 1
+		}
+		return 0
+	}
+	for _, e := range entries {
+		if e.id == "" && e.ctxt == "" {
+			// header entry
+			if m := pluralForms.FindStringSubmatch(strings.Join(e.strs, "")); m != nil {
+				fn, err := compilePlural(m[1])
+				if err != nil {
+					return nil, nil, e.line, fmt.Errorf("invalid Plural-Forms: %w", err)
+				}
+				plural = fn
+			}
+			continue
+		}
+		if e.fuzzy || e.ctxt != "" || len(e.strs) == 0 || slices.Contains(e.strs, "") {
+			continue
+		}
+		if e.plural == "" {
+			messages[e.id] = message{text: e.strs[0]}
+		} else {
+			messages[e.id] = message{text: e.strs[0], indexed: e.strs}
+		}
+	}
+	return messages, plural, 0, nil
+}
+
+// pluralForms matches the plural expression of the Plural-Forms header.
+var pluralForms = regexp.MustCompile(`Plural-Forms:.*?plural\s*=\s*([^;\n]+)`)
+
+// pluralToken matches a token of a plural expression.
+var pluralToken = regexp.MustCompile(`^\s*(\d+|n|\|\||&&|==|!=|<=|>=|[?:<>+\-*/%!()])`)
+
+// pluralExpr is a compiled plural expression.
+type pluralExpr func(n int) int
+
+// compilePlural compiles the C expression of a Plural-Forms header into a func that returns the plural form index of n.
+func compilePlural(expr string) (func(int) int, error) {
+	var tokens []string
+	rest := expr
+	for strings.TrimSpace(rest) != "" {
+		m := pluralToken.FindStringSubmatchIndex(rest)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected %q", strings.TrimSpace(rest))
+		}
+		tokens = append(tokens, rest[m[2]:m[3]])
+		rest = rest[m[1]:]
+	}
+	p := &pluralParser{tokens: tokens}
+	e, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+// pluralParser parses plural expression tokens with the precedence of C operators.
+type pluralParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *pluralParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *pluralParser) ternary() (pluralExpr, error) {
+	cond, err := p.binary(0)
+	if err != nil || p.peek() != "?" {
+		return cond, err
+	}
+	p.pos++
+	a, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ":" {
+		return nil, fmt.Errorf("expected : found %q", p.peek())
+	}
+	p.pos++
+	b, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if cond(n) != 0 {
+			return a(n)
+		}
+		return b(n)
+	}, nil
+}
+
+// pluralOperators are the binary operators from the lowest to the highest precedence.
+var pluralOperators = [][]string{{"||"}, {"&&"}, {"==", "!="}, {"<", ">", "<=", ">="}, {"+", "-"}, {"*", "/", "%"}}
+
+func (p *pluralParser) binary(level int) (pluralExpr, error) {
+	if level == len(pluralOperators) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for slices.Contains(pluralOperators[level], p.peek()) {
+		op := p.peek()
+		p.pos++
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(n int) int { return applyPluralOperator(op, l(n), right(n)) }
+	}
+	return left, nil
+}
+
+func (p *pluralParser) unary() (pluralExpr, error) {
+	tok := p.peek()
+	p.pos++
+	switch {
+	case tok == "!":
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int { return boolInt(e(n) == 0) }, nil
+	case tok == "n":
+		return func(n int) int { return n }, nil
+	case tok == "(":
+		e, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("expected ) found %q", p.peek())
+		}
+		p.pos++
+		return e, nil
+	case tok != "" && tok[0] >= '0' && tok[0] <= '9':
+		v, err := strconv.Atoi(tok)
+		if err != nil {
+			return nil, err
+		}
+		return func(int) int { return v }, nil
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q", tok)
+}
+
+func applyPluralOperator(op string, a, b int) int {
+	switch op {
+	case "||":
+		return boolInt(a != 0 || b != 0)
+	case "&&":
+		return boolInt(a != 0 && b != 0)
+	case "==":
+		return boolInt(a == b)
+	case "!=":
+		return boolInt(a != b)
+	case "<":
+		return boolInt(a < b)
+	case ">":
+		return boolInt(a > b)
+	case "<=":
+		return boolInt(a <= b)
+	case ">=":
+		return boolInt(a >= b)
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/", "%":
+		if b == 0 {
+			return 0
+		}
+		if op == "/" {
+			return a / b
+		}
+		return a % b
+	}
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
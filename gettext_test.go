@@ -0,0 +1,107 @@
+package tmpl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompilePlural(t *testing.T) {
+	tests := []struct {
+		expr    string
+		indexes []int // plural form index of n from 0 to 5
+		err     string
+	}{
+		{expr: "n != 1", indexes: []int{1, 0, 1, 1, 1, 1}},
+		{expr: "(n > 1)", indexes: []int{0, 0, 1, 1, 1, 1}},
+		{expr: "0", indexes: []int{0, 0, 0, 0, 0, 0}},
+		{expr: "n==1 ? 0 : n==2 ? 1 : n%2==0 ? 2 : 3", indexes: []int{2, 0, 1, 3, 2, 3}},
+		{expr: "!(n >= 2 && n <= 4) ? 0 : 1", indexes: []int{0, 0, 1, 1, 1, 0}},
+		{expr: "n / 0 + n % 0", indexes: []int{0, 0, 0, 0, 0, 0}},
+		{expr: "n >", err: "unexpected end of expression"},
+		{expr: "n ? 1", err: `expected : found ""`},
+		{expr: "(n", err: `expected ) found ""`},
+		{expr: "n $ 1", err: `unexpected "$ 1"`},
+		{expr: "n 1", err: `unexpected "1"`},
+	}
+	for _, test := range tests {
+		fn, err := compilePlural(test.expr)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected err: %q got: %v", test.expr, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected err: %v", test.expr, err)
+			continue
+		}
+		var indexes []int
+		for n := range 6 {
+			indexes = append(indexes, fn(n))
+		}
+		if !slices.Equal(indexes, test.indexes) {
+			t.Errorf("%s: expected: %v got: %v", test.expr, test.indexes, indexes)
+		}
+	}
+}
+
+func TestParsePO(t *testing.T) {
+	messages, plural, _, err := parsePO(`# translator comment
+msgid ""
+msgstr ""
+"Language: fr\n"
+"Plural-Forms: nplurals=2; plural=(n > 1);\n"
+
+#: pages/index.html
+msgid ""
+"Welcome "
+"back"
+msgstr ""
+"Bon retour "
+"parmi nous"
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Ouvrir"
+
+msgid "Untranslated"
+msgstr ""
+
+#, fuzzy
+msgid "Fuzzy"
+msgstr "Flou"
+
+msgid "file"
+msgid_plural "files"
+msgstr[0] "fichier"
+msgstr[1] "fichiers"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := messages["Welcome back"]; m.text != "Bon retour parmi nous" {
+		t.Errorf("expected continued strings to be joined, got: %q", m.text)
+	}
+	for _, key := range []string{"Open", "Untranslated", "Fuzzy"} {
+		if _, ok := messages[key]; ok {
+			t.Errorf("expected %q to be skipped", key)
+		}
+	}
+	if m := messages["file"]; !slices.Equal(m.indexed, []string{"fichier", "fichiers"}) {
+		t.Errorf("expected plural forms, got: %q", m.indexed)
+	}
+	if plural(1) != 0 || plural(2) != 1 {
+		t.Errorf("expected plural forms from header")
+	}
+
+	for _, text := range []string{
+		`msgstr "a"`,
+		"msgid \"a\"\n\"b",
+		"msgid \"a\"\nmsgstr[x] \"b\"",
+		"\"a\"",
+	} {
+		if _, _, line, err := parsePO(text); err == nil || line == 0 {
+			t.Errorf("%q: expected error with line, got: %v at line %d", text, err, line)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package tmpl
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Graph is the dependency graph of the parsed templates.
+//
+// Nodes are template files and edges point from a template to the layouts and autoloaded templates it uses.
+// Graph can be encoded as JSON with encoding/json or as DOT with WriteDOT.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a template in the dependency graph.
+type GraphNode struct {
+	// Name is the template name.
+	Name string `json:"name"`
+
+	// Kind is the kind of template, one of "page", "layout" or "component".
+	// Pages are loaded templates, layouts are layout templates and components are autoloaded templates.
+	Kind string `json:"kind"`
+}
+
+// GraphEdge is a dependency between two templates.
+type GraphEdge struct {
+	// From is the name of the template that depends on To.
+	From string `json:"from"`
+
+	// To is the name of the template that From depends on.
+	To string `json:"to"`
+
+	// Kind is the kind of dependency, one of "layout", "template", "tmpl", "stream", "render" or "component".
+	Kind string `json:"kind"`
+}
+
+// Graph returns the dependency graph of the parsed templates.
+//
+// Each loaded template depends on it's layout chain, and any template depends on the autoloaded templates
+// it references with {{ template "name" }} actions and tmpl, stream and render calls with a constant template name
+// and the templates of the registered components it calls with component.
+// A reference to a template defined in an autoloaded file is a dependency on that file.
+// Lazy templates are parsed first and skipped if they fail to parse.
+func (t Templates) Graph() Graph {
+	var g Graph
+	nodes := make(map[string]string)
+	edges := make(map[GraphEdge]bool)
+	// node names by file template name, loaded templates in group directories are named differently from their file
+	fileNodes := make(map[string]string)
+	addNode := func(name, kind string) {
+		// pages and components are not demoted to layouts
+		if _, ok := nodes[name]; !ok || kind != "layout" {
+			nodes[name] = kind
+		}
+	}
+
+	entries := t.Entries()
+	for _, meta := range entries {
+		if meta.Autoloaded {
+			addNode(meta.Name, "component")
+			fileNodes[meta.Name] = meta.Name
+			continue
+		}
+		addNode(meta.Name, "page")
+		if len(meta.Files) > 0 {
+			fileNodes[meta.Files[len(meta.Files)-1].Name] = meta.Name
+		}
+	}
+	for _, meta := range entries {
+		if meta.Autoloaded {
+			continue
+		}
+		from := meta.Name
+		for i := len(meta.Layouts) - 1; i >= 0; i-- {
+			layout := meta.Layouts[i]
+			addNode(layout, "layout")
+			edges[GraphEdge{From: from, To: layout, Kind: "layout"}] = true
+			from = layout
+		}
+	}
+
+	for _, name := range t.names() {
+		tmpl, err := t.lookup(name)
+		if err != nil || tmpl == nil {
+			continue
+		}
+		for _, assoc := range tmpl.Templates() {
+			if assoc.Tree == nil || assoc.Tree.Root == nil {
+				continue
+			}
+			for _, ref := range templateReferences(assoc.Name(), assoc.Tree) {
+				target := t.referenced(tmpl, ref)
+				if target == nil || target.Tree == nil || target.Tree.ParseName == assoc.Tree.ParseName {
+					continue
+				}
+				if meta, ok := t.metadata[target.Tree.ParseName]; !ok || !meta.Autoloaded {
+					continue
+				}
+				from := cmp.Or(fileNodes[assoc.Tree.ParseName], assoc.Tree.ParseName)
+				if _, ok := nodes[from]; !ok {
+					addNode(from, "layout")
+				}
+				edges[GraphEdge{From: from, To: target.Tree.ParseName, Kind: ref.Kind}] = true
+			}
+		}
+	}
+
+	for name, kind := range nodes {
+		g.Nodes = append(g.Nodes, GraphNode{Name: name, Kind: kind})
+	}
+	slices.SortFunc(g.Nodes, func(a, b GraphNode) int { return strings.Compare(a.Name, b.Name) })
+	for edge := range edges {
+		g.Edges = append(g.Edges, edge)
+	}
+	slices.SortFunc(g.Edges, func(a, b GraphEdge) int {
+		return cmp.Or(strings.Compare(a.From, b.From), strings.Compare(a.To, b.To), strings.Compare(a.Kind, b.Kind))
+	})
+	return g
+}
+
+// graphNodeShapes are the DOT node shapes of each node kind.
+var graphNodeShapes = map[string]string{
+	"page":      "box",
+	"layout":    "folder",
+	"component": "component",
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language to w.
+func (g Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph templates {\n\trankdir=LR;\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&sb, "\t%q [shape=%s];\n", node.Name, graphNodeShapes[node.Kind])
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&sb, "\t%q -> %q [label=%q];\n", edge.From, edge.To, edge.Kind)
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,91 @@
+package tmpl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGraph(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html":  {Data: []byte(`<button>{{ template "components/icon" }}</button>`)},
+		"components/icon.html":    {Data: []byte(`<svg></svg>`)},
+		"components/card.html":    {Data: []byte(`{{ define "card" }}<div></div>{{ end }}`)},
+		"pages/layout.html":       {Data: []byte(`<main>{{ template "content" . }}</main>`)},
+		"pages/sub/layout.html":   {Data: []byte(`<div>{{ template "content" . }}</div>`)},
+		"pages/sub/index.html":    {Data: []byte(`{{ define "content" }}{{ template "components/button" }}{{ slot (tmpl "card" .) }}{{ end }}`)},
+		"pages/(group)/page.html": {Data: []byte(`{{ stream "card" .Data }}`)},
+	}
+	g := New(fs).Autoload("components").LoadTree("pages").MustParse().Graph()
+
+	expectedNodes := []GraphNode{
+		{"components/button", "component"},
+		{"components/card", "component"},
+		{"components/icon", "component"},
+		{"pages/layout", "layout"},
+		{"pages/page", "page"},
+		{"pages/sub/index", "page"},
+		{"pages/sub/layout", "layout"},
+	}
+	if !slices.Equal(expectedNodes, g.Nodes) {
+		t.Errorf("expected: %v, got: %v", expectedNodes, g.Nodes)
+	}
+	expectedEdges := []GraphEdge{
+		{"components/button", "components/icon", "template"},
+		{"pages/page", "components/card", "stream"},
+		{"pages/page", "pages/layout", "layout"},
+		{"pages/sub/index", "components/button", "template"},
+		{"pages/sub/index", "components/card", "tmpl"},
+		{"pages/sub/index", "pages/sub/layout", "layout"},
+		{"pages/sub/layout", "pages/layout", "layout"},
+	}
+	if !slices.Equal(expectedEdges, g.Edges) {
+		t.Errorf("expected: %v, got: %v", expectedEdges, g.Edges)
+	}
+
+	var sb strings.Builder
+	if err := g.WriteDOT(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`"components/button" [shape=component];`,
+		`"pages/sub/index" -> "pages/sub/layout" [label="layout"];`,
+	} {
+		if !strings.Contains(sb.String(), line) {
+			t.Errorf("expected DOT output to contain %q, got: %q", line, sb.String())
+		}
+	}
+}
+
+func TestGraphRender(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/card.html":  {Data: []byte(`<div>{{ slot .children }}</div>`)},
+		"components/alert.html": {Data: []byte(`<p>{{ . }}</p>`)},
+		"pages/index.html":      {Data: []byte(`{{ #render "components/card" }}<p>{{ . }}</p>{{ /render }}{{ render "components/alert" . }}`)},
+	}
+	g := New(fs).Preprocess(BlockPreprocessor).Autoload("components").LoadTree("pages").MustParse().Graph()
+
+	expectedEdges := []GraphEdge{
+		{"pages/index", "components/alert", "render"},
+		{"pages/index", "components/card", "render"},
+	}
+	if !slices.Equal(expectedEdges, g.Edges) {
+		t.Errorf("expected: %v, got: %v", expectedEdges, g.Edges)
+	}
+}
+
+func TestGraphComponents(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html": {Data: []byte(`<button>{{ .Label }}</button>`)},
+		"pages/index.html":       {Data: []byte(`{{ component "button" (map "label" "Save") }}`)},
+	}
+	g := New(fs).Component("button", "components/button", nil).Autoload("components").LoadTree("pages").MustParse().Graph()
+
+	expectedEdges := []GraphEdge{
+		{"pages/index", "components/button", "component"},
+	}
+	if !slices.Equal(expectedEdges, g.Edges) {
+		t.Errorf("expected: %v, got: %v", expectedEdges, g.Edges)
+	}
+}
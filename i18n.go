@@ -0,0 +1,399 @@
+package tmpl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// message is a message of a catalog.
+type message struct {
+	// text is the message or the singular form of a plural message.
+	text string
+
+	// forms are the plural forms of a JSON message by CLDR plural category.
+	forms map[string]string
+
+	// indexed are the plural forms of a gettext message by plural form index.
+	indexed []string
+}
+
+// catalog is the messages of a locale.
+type catalog struct {
+	locale   string
+	messages map[string]message
+
+	// plural returns the gettext plural form index of n.
+	plural func(n int) int
+}
+
+// pluralForm returns the plural form of m for n.
+func (c *catalog) pluralForm(m message, n int) string {
+	switch {
+	case m.indexed != nil:
+		i := 0
+		if c.plural != nil {
+			i = c.plural(n)
+		} else if n != 1 {
+			i = 1
+		}
+		if i < 0 || i >= len(m.indexed) {
+			i = len(m.indexed) - 1
+		}
+		return m.indexed[i]
+	case m.forms != nil:
+		if s, ok := m.forms[pluralCategory(c.locale, n)]; ok {
+			return s
+		}
+		return m.forms["other"]
+	}
+	return m.text
+}
+
+// translations are the catalogs of all locales loaded with I18n.
+type translations struct {
+	fallback string
+	catalogs map[string]*catalog
+}
+
+// localized are the templates of all locales by locale.
+type localized struct {
+	fallback  string
+	templates map[string]Templates
+}
+
+// I18n loads the message catalogs in dir and registers the translation and formatting funcs,
+// the fallback locale is used for messages missing in a locale and for rendering without a locale.
+//
+// Catalogs are JSON or gettext PO files named after their locale, ie. "locales/fr.json" or "locales/pt-BR.po".
+// JSON catalogs map keys to messages, nested objects are flattened to dotted keys
+// and objects with CLDR plural categories ("zero", "one", "two", "few", "many" and "other") are plural messages.
+// Gettext catalogs use the msgid as the key and the Plural-Forms header to select plural forms,
+// fuzzy messages and messages with a context are skipped.
+//
+// The registered funcs are:
+//
+//	t key [args]                   translates key, args are key value pairs or a map for {name} or {name, number} placeholders
+//	tn key count [args]            translates the plural form of key for count, {count} is replaced with count
+//	formatNumber number [decimals] formats a number with the separators of the locale
+//	formatDate time [style]        formats a time with the "short", "medium", "long" or "full" style or a layout
+//	locale                         returns the locale
+//
+// Parse clones the templates for each locale, use Templates.WithLocale to choose the locale of a render.
+// I18n must be called before loading templates that use the funcs, like Funcs.
+func (t *templatesParser) I18n(dir string, fallback string) *templatesParser {
+	tr := &translations{fallback: fallback, catalogs: make(map[string]*catalog)}
+	if t.i18n != nil {
+		maps.Copy(tr.catalogs, t.i18n.catalogs)
+	}
+	t.i18n = tr
+	t.templates.templates["<root>"].Funcs(tr.funcs(fallback))
+
+	files, err := t.catalogFiles(dir)
+	if err != nil {
+		t.addErr(err)
+		return t
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		ext := path.Ext(name)
+		file := path.Join(dir, name)
+		locale := strings.ReplaceAll(strings.TrimSuffix(name, ext), "_", "-")
+		text := files[name]
+		var messages map[string]message
+		var plural func(int) int
+		var line int
+		if ext == ".json" {
+			messages, line, err = parseJSONCatalog([]byte(text))
+		} else {
+			messages, plural, line, err = parsePO(text)
+		}
+		if err != nil {
+			t.addErr(&LoadError{Name: file, File: file, Line: line, Err: err})
+			continue
+		}
+		// catalogs may be shared with clones of the parser so they are copied before merging
+		c := &catalog{locale: locale, messages: make(map[string]message)}
+		if prev, ok := tr.catalogs[normalizeLocale(locale)]; ok {
+			c.messages, c.plural = maps.Clone(prev.messages), prev.plural
+		}
+		maps.Copy(c.messages, messages)
+		if plural != nil {
+			c.plural = plural
+		}
+		tr.catalogs[normalizeLocale(locale)] = c
+	}
+	if len(files) == 0 && t.strict {
+		t.addErr(newLoadError(dir, dir, errors.New("no message catalogs found")))
+	}
+	return t
+}
+
+// parseJSONCatalog parses the messages of a JSON catalog.
+// A syntax error reports the line of the error.
+func parseJSONCatalog(b []byte) (map[string]message, int, error) {
+	var v map[string]any
+	if err := json.Unmarshal(b, &v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, bytes.Count(b[:syntaxErr.Offset], []byte("\n")) + 1, err
+		}
+		return nil, 0, err
+	}
+	messages := make(map[string]message)
+	return messages, 0, flattenMessages(messages, "", v)
+}
+
+// pluralCategories are the CLDR plural categories.
+var pluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+// flattenMessages adds the messages in v to messages with their keys prefixed.
+func flattenMessages(messages map[string]message, prefix string, v map[string]any) error {
+	for k, v := range v {
+		key := prefix + k
+		switch v := v.(type) {
+		case string:
+			messages[key] = message{text: v}
+		case map[string]any:
+			forms, ok := pluralMessage(v)
+			if !ok {
+				if err := flattenMessages(messages, key+".", v); err != nil {
+					return err
+				}
+				continue
+			}
+			messages[key] = message{text: forms["other"], forms: forms}
+		default:
+			return fmt.Errorf("message %s: expected string or object found %T", key, v)
+		}
+	}
+	return nil
+}
+
+// pluralMessage returns the plural forms of v if all keys are plural categories including "other".
+func pluralMessage(v map[string]any) (map[string]string, bool) {
+	if _, ok := v["other"]; !ok {
+		return nil, false
+	}
+	forms := make(map[string]string, len(v))
+	for k, v := range v {
+		s, ok := v.(string)
+		if !ok || !slices.Contains(pluralCategories, k) {
+			return nil, false
+		}
+		forms[k] = s
+	}
+	return forms, true
+}
+
+// lookup returns the message key in the catalog of the locale, it's language or the fallback locale.
+func (tr *translations) lookup(locale, key string) (message, *catalog, bool) {
+	for _, l := range []string{normalizeLocale(locale), language(locale), normalizeLocale(tr.fallback)} {
+		if c, ok := tr.catalogs[l]; ok {
+			if m, ok := c.messages[key]; ok {
+				return m, c, true
+			}
+		}
+	}
+	return message{}, nil, false
+}
+
+// funcs returns the translation and formatting funcs for the locale.
+func (tr *translations) funcs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, args ...any) (string, error) {
+			values, err := keyValues(args)
+			if err != nil {
+				return "", fmt.Errorf("t %q: %w", key, err)
+			}
+			m, _, ok := tr.lookup(locale, key)
+			if !ok {
+				return key, nil
+			}
+			return interpolate(locale, m.text, values), nil
+		},
+		"tn": func(key string, count any, args ...any) (string, error) {
+			n, err := toCount(count)
+			if err != nil {
+				return "", fmt.Errorf("tn %q: %w", key, err)
+			}
+			values, err := keyValues(args)
+			if err != nil {
+				return "", fmt.Errorf("tn %q: %w", key, err)
+			}
+			if _, ok := values["count"]; !ok {
+				values["count"] = count
+			}
+			m, c, ok := tr.lookup(locale, key)
+			if !ok {
+				return key, nil
+			}
+			return interpolate(locale, c.pluralForm(m, n), values), nil
+		},
+		"formatNumber": func(v any, decimals ...int) (string, error) {
+			d := -1
+			if len(decimals) > 0 {
+				d = decimals[0]
+			}
+			return formatNumber(locale, v, d)
+		},
+		"formatDate": func(v any, style ...string) (string, error) {
+			s := "medium"
+			if len(style) > 0 {
+				s = style[0]
+			}
+			return formatDate(locale, v, s)
+		},
+		"locale": func() string {
+			return locale
+		},
+	}
+}
+
+// keyValues returns a copy of the values of key value pairs or a map.
+func keyValues(args []any) (map[string]any, error) {
+	if len(args) == 0 {
+		return make(map[string]any), nil
+	}
+	if _, ok := args[0].(string); len(args) == 1 && !ok {
+		m, err := toMap(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return maps.Clone(m), nil
+	}
+	return mapFunc(args...)
+}
+
+// interpolate replaces {name} placeholders in s with the values formatted for the locale.
+// A {name, number} placeholder formats the value as a number with the separators of the locale.
+// Placeholders without a value are kept.
+func interpolate(locale, s string, values map[string]any) string {
+	if len(values) == 0 {
+		return s
+	}
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(s, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		sb.WriteString(s[:start])
+		name, format, _ := strings.Cut(s[start+1:end], ",")
+		if v, ok := values[strings.TrimSpace(name)]; ok {
+			sb.WriteString(formatValue(locale, v, strings.TrimSpace(format)))
+		} else {
+			sb.WriteString(s[start : end+1])
+		}
+		s = s[end+1:]
+	}
+	sb.WriteString(s)
+	return sb.String()
+}
+
+// formatValue formats a placeholder value for the locale,
+// numbers are only formatted with the number format and times are formatted with the medium date style.
+func formatValue(locale string, v any, format string) string {
+	if format == "number" {
+		if s, err := formatNumber(locale, v, -1); err == nil {
+			return s
+		}
+	}
+	if t, ok := v.(time.Time); ok {
+		s, _ := formatDate(locale, t, "medium")
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+// localize sets the locale of templates to the fallback locale
+// and clones templates for every other locale with the funcs of that locale.
+// Templates cannot be cloned after they are executed.
+func (t *templatesParser) localize(templates Templates) (Templates, error) {
+	tr := t.i18n
+	l := &localized{fallback: tr.fallback, templates: make(map[string]Templates, len(tr.catalogs)+1)}
+	templates.locale, templates.locales = tr.fallback, l
+	l.templates[normalizeLocale(tr.fallback)] = templates
+	for key, c := range tr.catalogs {
+		if _, ok := l.templates[key]; ok {
+			continue
+		}
+		lt := Templates{
+			templates:  make(map[string]*template.Template, len(templates.templates)),
+			metadata:   templates.metadata,
+			lazy:       make(map[string]*lazyTemplate, len(templates.lazy)),
+			locale:     c.locale,
+			locales:    l,
+			urls:       templates.urls,
+			components: templates.components,
+		}
+		funcs := tr.funcs(c.locale)
+		for name, tmpl := range templates.templates {
+			clone, err := tmpl.Clone()
+			if err != nil {
+				return Templates{}, err
+			}
+			lt.templates[name] = clone.Funcs(contextFuncMap(clone, t.components)).Funcs(funcs)
+		}
+		if templates.lazyRoot != nil {
+			root, err := templates.lazyRoot.Clone()
+			if err != nil {
+				return Templates{}, err
+			}
+			lt.lazyRoot = root.Funcs(funcs)
+		}
+		for name, lazy := range templates.lazy {
+			lt.lazy[name] = &lazyTemplate{name: lazy.name, files: lazy.files, parse: lazy.parse, components: lazy.components}
+		}
+		lt, err := t.withContexts(lt)
+		if err != nil {
+			return Templates{}, err
+		}
+		l.templates[key] = lt
+	}
+	return templates, nil
+}
+
+// WithLocale returns the templates that render with the first of the preferred locales that has a catalog,
+// a locale also matches the catalog of it's language, ie. "fr-CA" matches "fr".
+// The templates of the fallback locale are returned if no preferred locale matches.
+//
+// Quality values are ignored so the values of an Accept-Language header can be passed in order,
+// ie. WithLocale(strings.Split(r.Header.Get("Accept-Language"), ",")...).
+// WithLocale returns the templates as is if I18n was not used.
+func (t Templates) WithLocale(preferred ...string) Templates {
+	if t.locales == nil {
+		return t
+	}
+	lt := t.locales.templates[normalizeLocale(t.locales.fallback)]
+	for _, locale := range preferred {
+		locale, _, _ = strings.Cut(locale, ";")
+		if match, ok := t.locales.templates[normalizeLocale(locale)]; ok {
+			lt = match
+			break
+		}
+		if match, ok := t.locales.templates[language(locale)]; ok {
+			lt = match
+			break
+		}
+	}
+	// keep the render context
+	lt.ctx, lt.values = t.ctx, t.values
+	return lt
+}
+
+// Locale returns the locale the templates render with or an empty string if I18n was not used.
+func (t Templates) Locale() string {
+	return t.locale
+}
@@ -0,0 +1,209 @@
+package tmpl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestI18n(t *testing.T) {
+	fs := fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{
+			"hello": "Hello {name}",
+			"nav": {"home": "Home"},
+			"items": {"one": "{count} item", "other": "{count} items"},
+			"only_en": "English only"
+		}`)},
+		"locales/fr.json": {Data: []byte(`{
+			"hello": "Bonjour {name}",
+			"nav": {"home": "Accueil"},
+			"items": {"one": "{count} article", "other": "{count} articles"}
+		}`)},
+		"locales/ru.po": {Data: []byte(`
+msgid ""
+msgstr ""
+"Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid "hello"
+msgstr "Привет {name}"
+
+msgid "items"
+msgid_plural "items"
+msgstr[0] "{count} предмет"
+msgstr[1] "{count} предмета"
+msgstr[2] "{count} предметов"
+
+#, fuzzy
+msgid "nav.home"
+msgstr "Главная"
+`)},
+		"pages/index.html": {Data: []byte(`{{ locale }}|{{ t "hello" "name" .Name }}|{{ t "nav.home" }}|{{ tn "items" .Count }}|{{ t "only_en" }}|{{ t "missing" }}|{{ formatNumber 1234567.5 }}|{{ formatDate .Date }}`)},
+	}
+	data := map[string]any{"Name": "Ana", "Count": 5, "Date": time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)}
+
+	for _, lazy := range []bool{false, true} {
+		templates, err := New(fs).I18n("locales", "en").Lazy(lazy).LoadTree("pages").Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			locales []string
+			output  string
+		}{
+			{nil, "en|Hello Ana|Home|5 items|English only|missing|1,234,567.5|Mar 5, 2024"},
+			{[]string{"fr"}, "fr|Bonjour Ana|Accueil|5 articles|English only|missing|1 234 567,5|5 mars 2024"},
+			{[]string{"fr-CA;q=0.9", "en"}, "fr|Bonjour Ana|Accueil|5 articles|English only|missing|1 234 567,5|5 mars 2024"},
+			{[]string{"de", "ru"}, "ru|Привет Ana|Home|5 предметов|English only|missing|1,234,567.5|Mar 5, 2024"},
+			{[]string{"es"}, "en|Hello Ana|Home|5 items|English only|missing|1,234,567.5|Mar 5, 2024"},
+		}
+		for _, test := range tests {
+			var sb strings.Builder
+			if err := templates.WithLocale(test.locales...).Render(&sb, Tmpl("pages/index", data)); err != nil {
+				t.Fatal(err)
+			}
+			if sb.String() != test.output {
+				t.Errorf("lazy: %v, locales: %v: expected: %q, got: %q", lazy, test.locales, test.output, sb.String())
+			}
+		}
+		if locale := templates.WithLocale("fr").WithLocale("ru").Locale(); locale != "ru" {
+			t.Errorf("expected locale: %q, got: %q", "ru", locale)
+		}
+	}
+}
+
+func TestI18nPlurals(t *testing.T) {
+	fs := fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{"items": {"one": "{count} item", "other": "{count, number} items"}}`)},
+		"locales/fr.json": {Data: []byte(`{"items": {"one": "{count} article", "other": "{count} articles"}}`)},
+		"locales/ru.po": {Data: []byte(`msgid ""
+msgstr "Plural-Forms: nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgid "items"
+msgid_plural "items"
+msgstr[0] "{count} предмет"
+msgstr[1] "{count} предмета"
+msgstr[2] "{count} предметов"
+`)},
+		"pages/index.html": {Data: []byte(`{{ tn "items" . }}`)},
+	}
+	templates, err := New(fs).I18n("locales", "en").LoadTree("pages").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		locale string
+		count  int
+		output string
+	}{
+		{"en", 0, "0 items"},
+		{"en", 1, "1 item"},
+		{"en", 1000, "1,000 items"},
+		{"fr", 0, "0 article"},
+		{"fr", 2, "2 articles"},
+		{"ru", 1, "1 предмет"},
+		{"ru", 3, "3 предмета"},
+		{"ru", 11, "11 предметов"},
+		{"ru", 21, "21 предмет"},
+	}
+	for _, test := range tests {
+		var sb strings.Builder
+		if err := templates.WithLocale(test.locale).Render(&sb, Tmpl("pages/index", test.count)); err != nil {
+			t.Fatal(err)
+		}
+		if sb.String() != test.output {
+			t.Errorf("%s %d: expected: %q, got: %q", test.locale, test.count, test.output, sb.String())
+		}
+	}
+}
+
+func TestI18nFormat(t *testing.T) {
+	date := time.Date(2024, time.May, 6, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		locale string
+		text   string
+		output string
+	}{
+		{"en", `{{ formatNumber -1234 }}`, "-1,234"},
+		{"en", `{{ formatNumber 1234.5 2 }}`, "1,234.50"},
+		{"de", `{{ formatNumber 1234567.891 2 }}`, "1.234.567,89"},
+		{"en", `{{ formatDate . "short" }}|{{ formatDate . "full" }}`, "5/6/24|Monday, May 6, 2024"},
+		{"de", `{{ formatDate . "short" }}|{{ formatDate . "full" }}`, "06.05.24|Montag, 6. Mai 2024"},
+		{"es", `{{ formatDate . "long" }}`, "6 de mayo de 2024"},
+		{"fr", `{{ formatDate . "Mon 2 Jan" }}`, "lun. 6 mai"},
+		{"en", `{{ formatNumber "1" }}`, `expected number found string`},
+		{"en", `{{ formatDate 1 }}`, `expected time.Time found int`},
+		{"en", `{{ t "hello" "name" }}`, `t "hello": key name missing value`},
+	}
+	for _, test := range tests {
+		fs := fstest.MapFS{
+			"locales/" + test.locale + ".json": {Data: []byte(`{}`)},
+			"pages/index.html":                 {Data: []byte(test.text)},
+		}
+		templates, err := New(fs).I18n("locales", test.locale).LoadTree("pages").Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		if err := templates.Render(&sb, Tmpl("pages/index", date)); err != nil {
+			if !strings.Contains(err.Error(), test.output) {
+				t.Errorf("%s: expected err: %q, got: %v", test.text, test.output, err)
+			}
+			continue
+		}
+		if sb.String() != test.output {
+			t.Errorf("%s %s: expected: %q, got: %q", test.locale, test.text, test.output, sb.String())
+		}
+	}
+}
+
+func TestI18nPlaceholders(t *testing.T) {
+	fs := fstest.MapFS{
+		"locales/en.json":  {Data: []byte(`{"copyright": "© {year}", "order": "Order #{id}: {total, number} items"}`)},
+		"locales/fr.json":  {Data: []byte(`{"copyright": "© {year}", "order": "Commande n°{id} : {total, number} articles"}`)},
+		"pages/index.html": {Data: []byte(`{{ t "copyright" (map "year" 2024) }}|{{ t "order" "id" 10245 "total" 1500 }}`)},
+	}
+	templates := New(fs).I18n("locales", "en").LoadTree("pages").MustParse()
+	tests := []struct {
+		locale string
+		output string
+	}{
+		{"en", "© 2024|Order #10245: 1,500 items"},
+		{"fr", "© 2024|Commande n°10245 : 1\u202f500 articles"},
+	}
+	for _, test := range tests {
+		var sb strings.Builder
+		if err := templates.WithLocale(test.locale).Render(&sb, Tmpl("pages/index", nil)); err != nil {
+			t.Fatal(err)
+		}
+		if sb.String() != test.output {
+			t.Errorf("%s: expected: %q, got: %q", test.locale, test.output, sb.String())
+		}
+	}
+}
+
+func TestI18nErrors(t *testing.T) {
+	tests := []struct {
+		fs   fstest.MapFS
+		file string
+		line int
+	}{
+		{fstest.MapFS{"locales/en.json": {Data: []byte("{\n\"a\": \"b\",\n}")}}, "locales/en.json", 3},
+		{fstest.MapFS{"locales/en.json": {Data: []byte(`{"a": 1}`)}}, "locales/en.json", 0},
+		{fstest.MapFS{"locales/en.po": {Data: []byte("msgid \"a\"\nmsgstr \"b\"\nmsgfoo \"c\"")}}, "locales/en.po", 3},
+		{fstest.MapFS{"locales/en.po": {Data: []byte("msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=n >;\\n\"")}}, "locales/en.po", 1},
+		{fstest.MapFS{"other/en.json": {Data: []byte(`{}`)}}, "locales", 0},
+	}
+	for _, test := range tests {
+		_, err := New(test.fs).I18n("locales", "en").Parse()
+		var loadErr *LoadError
+		if !errors.As(err, &loadErr) {
+			t.Errorf("expected LoadError, got: %v", err)
+			continue
+		}
+		if loadErr.File != test.file || loadErr.Line != test.line {
+			t.Errorf("expected error at %s:%d, got: %v", test.file, test.line, err)
+		}
+	}
+}
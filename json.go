@@ -0,0 +1,46 @@
+package tmpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+)
+
+// jsonFunc returns v encoded as JSON.
+//
+// The result is a template.JS so it is inserted as is in script contexts and escaped in attribute contexts.
+// json.Marshal escapes <, > and & so the result cannot close a script element.
+func jsonFunc(v any) (template.JS, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(b), nil
+}
+
+// jsonScriptFunc returns a script element with type application/json and the id containing v encoded as JSON.
+// The data can be read in the browser with JSON.parse(document.getElementById(id).textContent).
+func jsonScriptFunc(id string, v any) (template.HTML, error) {
+	if id == "" {
+		return "", fmt.Errorf("jsonScript: id must not be empty")
+	}
+	b, err := jsonFunc(v)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(fmt.Sprintf(`<script type="application/json" id="%s">%s</script>`, html.EscapeString(id), b)), nil
+}
+
+// hydrateFunc returns the data-hydrate and data-props attributes which mount the client component id with v as props.
+// Render the attributes on the element the component mounts on, ie. <div {{ hydrate "Counter" .Data }}>...</div>.
+func hydrateFunc(id string, v any) (template.HTMLAttr, error) {
+	if id == "" {
+		return "", fmt.Errorf("hydrate: id must not be empty")
+	}
+	b, err := jsonFunc(v)
+	if err != nil {
+		return "", err
+	}
+	return template.HTMLAttr(fmt.Sprintf(`data-hydrate="%s" data-props="%s"`, html.EscapeString(id), html.EscapeString(string(b)))), nil
+}
@@ -0,0 +1,75 @@
+package tmpl
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	data := map[string]any{"name": `</script><b>"Tom" & 'Jerry'`, "count": 2}
+	tests := []struct {
+		text   string
+		data   any
+		output string
+		err    string
+	}{
+		{
+			text:   `<script>const data = {{ json . }};</script>`,
+			data:   data,
+			output: `<script>const data = {"count":2,"name":"\u003c/script\u003e\u003cb\u003e\"Tom\" \u0026 'Jerry'"};</script>`,
+		},
+		{
+			text:   `<script type="application/json">{{ json . }}</script>`,
+			data:   data,
+			output: `<script type="application/json">{"count":2,"name":"\u003c/script\u003e\u003cb\u003e\"Tom\" \u0026 'Jerry'"}</script>`,
+		},
+		{
+			text:   `<div data-props="{{ json . }}"></div>`,
+			data:   data,
+			output: `<div data-props="{&#34;count&#34;:2,&#34;name&#34;:&#34;\u003c/script\u003e\u003cb\u003e\&#34;Tom\&#34; \u0026 &#39;Jerry&#39;&#34;}"></div>`,
+		},
+		{
+			text:   `{{ jsonScript "page-data" . }}`,
+			data:   data,
+			output: `<script type="application/json" id="page-data">{"count":2,"name":"\u003c/script\u003e\u003cb\u003e\"Tom\" \u0026 'Jerry'"}</script>`,
+		},
+		{
+			text:   `<div {{ hydrate "Counter" . }}></div>`,
+			data:   map[string]any{"count": 1, "label": `"a" <b>`},
+			output: `<div data-hydrate="Counter" data-props="{&#34;count&#34;:1,&#34;label&#34;:&#34;\&#34;a\&#34; \u003cb\u003e&#34;}"></div>`,
+		},
+		{
+			text: `{{ json . }}`,
+			data: make(chan int),
+			err:  "json: unsupported type: chan int",
+		},
+		{
+			text: `{{ jsonScript "" . }}`,
+			err:  "jsonScript: id must not be empty",
+		},
+		{
+			text: `<div {{ hydrate "" . }}></div>`,
+			err:  "hydrate: id must not be empty",
+		},
+	}
+
+	for _, test := range tests {
+		tp := template.Must(template.New("test").Funcs(funcMap).Parse(test.text))
+		var sb strings.Builder
+		err := tp.Execute(&sb, test.data)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected err: %q got: %v", test.text, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected err: %v", test.text, err)
+			continue
+		}
+		if sb.String() != test.output {
+			t.Errorf("%s: expected: %q got: %q", test.text, test.output, sb.String())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package tmpl
+
+import (
+	"html/template"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// lazyTemplate is a template indexed in lazy mode, it is parsed on first use and cached afterwards.
+type lazyTemplate struct {
+	name  string
+	files []string
+	parse func(root *template.Template, name string, files []string) (*template.Template, Metadata, error)
+
+	// components are bound to clones of the template that use the ctx func
+	components components
+
+	once    sync.Once
+	tmpl    *template.Template
+	meta    Metadata
+	context *contextPool
+	err     error
+}
+
+// load parses the template from a clone of root once, concurrent callers wait for the first parse.
+func (l *lazyTemplate) load(root *template.Template) (*template.Template, Metadata, error) {
+	l.once.Do(func() {
+		l.tmpl, l.meta, l.err = l.parse(root, l.name, l.files)
+		if l.err == nil {
+			// the context pool clones the template before it is executed
+			l.context, l.err = newContextPool(l.tmpl, l.components)
+		}
+	})
+	return l.tmpl, l.meta, l.err
+}
+
+// lazyTemplates returns the indexed lazy templates bound to a snapshot of the parser configuration,
+// so changes to the parser after Parse do not change how lazy templates are parsed.
+func (t *templatesParser) lazyTemplates() map[string]*lazyTemplate {
+	s := &templatesParser{
+		fsys:           t.fsys,
+		exts:           slices.Clone(t.exts),
+		layoutFilename: t.layoutFilename,
+		extractors:     maps.Clone(t.extractors),
+		preprocessors:  slices.Clone(t.preprocessors),
+		frontmatter:    slices.Clone(t.frontmatter),
+		skipFuncCheck:  t.skipFuncCheck,
+		onLoadFn:       t.onLoadFn,
+		onCollisionFn:  t.onCollisionFn,
+		defined:        maps.Clone(t.defined),
+		bundle:         t.bundle,
+		components:     maps.Clone(t.components),
+	}
+	// templates are parsed one at a time so parser callbacks are not called concurrently
+	var mu sync.Mutex
+	parse := func(root *template.Template, name string, files []string) (*template.Template, Metadata, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return s.parse(root, name, files)
+	}
+	lazy := make(map[string]*lazyTemplate, len(t.templates.lazy))
+	for name, l := range t.templates.lazy {
+		lazy[name] = &lazyTemplate{name: l.name, files: l.files, parse: parse, components: s.components}
+	}
+	return lazy
+}
+
+// lookup returns the loaded template with the given name, parsing it first if it is a lazy template.
+// lookup returns a nil template if there is no such template.
+func (t Templates) lookup(name string) (*template.Template, error) {
+	if tmpl, ok := t.templates[name]; ok {
+		return tmpl, nil
+	}
+	l, ok := t.lazy[name]
+	if !ok {
+		return nil, nil
+	}
+	tmpl, _, err := l.load(t.lazyRoot)
+	return tmpl, err
+}
+
+// names returns the names of all loaded and lazy templates.
+func (t Templates) names() []string {
+	names := make([]string, 0, len(t.templates)+len(t.lazy))
+	for name := range t.templates {
+		names = append(names, name)
+	}
+	for name := range t.lazy {
+		names = append(names, name)
+	}
+	return names
+}
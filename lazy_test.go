@@ -0,0 +1,111 @@
+package tmpl
+
+import (
+	"errors"
+	"html/template"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLazy(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html": {Data: []byte(`<button>{{ . }}</button>`)},
+		"pages/layout.html":      {Data: []byte(`<main>{{ template "content" . }}</main>`)},
+		"pages/index.html":       {Data: []byte(`{{ define "content" }}{{ template "components/button" . }}{{ end }}`)},
+		"pages/broken.html":      {Data: []byte(`{{ define "content" }}{{ end `)},
+	}
+	templates, err := New(fs).Lazy(true).Autoload("components").LoadTree("pages").Parse()
+	if err != nil {
+		t.Fatalf("expected lazy templates to defer parse errors, got: %v", err)
+	}
+	if len(templates.templates) != 1 {
+		t.Errorf("expected only the root template to be parsed, got: %d templates", len(templates.templates))
+	}
+	if routes := templates.Routes("pages"); len(routes) != 2 {
+		t.Errorf("expected lazy templates to be routed, got: %v", routes)
+	}
+
+	// render the autoloaded template with the root template before loading lazy templates
+	var sb strings.Builder
+	if err := templates.Render(&sb, Tmpl("components/button", "ok")); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	errs := make([]error, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var sb strings.Builder
+			errs[i] = templates.Render(&sb, Associated("pages/index", "pages/layout", "click"))
+			results[i] = sb.String()
+		}()
+	}
+	wg.Wait()
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if expected := "<main><button>click</button></main>"; result != expected {
+			t.Errorf("expected: %q, got: %q", expected, result)
+		}
+	}
+	if templates.Lookup("pages/index") != templates.Lookup("pages/index") {
+		t.Error("expected lazy template to be cached")
+	}
+	if meta, ok := templates.Metadata("pages/index"); !ok || len(meta.Layouts) != 1 {
+		t.Errorf("expected metadata with layout, got: %v", meta)
+	}
+
+	err = templates.Render(&sb, Tmpl("pages/broken", nil))
+	var le *LoadError
+	if !errors.As(err, &le) || le.File != "pages/broken.html" {
+		t.Errorf("expected load error for pages/broken.html, got: %v", err)
+	}
+}
+
+func TestLazySnapshot(t *testing.T) {
+	fs := fstest.MapFS{
+		"pages/index.html": {Data: []byte(`index`)},
+		"pages/about.html": {Data: []byte(`about`)},
+		"pages/blog.html":  {Data: []byte(`blog`)},
+	}
+	var loaded []string
+	tp := New(fs).Lazy(true).OnLoad(func(name string, _ *template.Template) {
+		loaded = append(loaded, name)
+	}).LoadTree("pages")
+	templates, err := tp.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// changes to the parser after Parse do not apply to lazy templates
+	tp.SetExt("txt").OnLoad(func(name string, _ *template.Template) {
+		t.Errorf("expected OnLoad set after Parse not to be called for %s", name)
+	})
+
+	var wg sync.WaitGroup
+	names := []string{"pages/index", "pages/about", "pages/blog"}
+	errs := make([]error, len(names))
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var sb strings.Builder
+			errs[i] = templates.Render(&sb, Tmpl(name, nil))
+			if expected := strings.TrimPrefix(name, "pages/"); sb.String() != expected {
+				t.Errorf("expected: %q, got: %q", expected, sb.String())
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(names) {
+		t.Errorf("expected OnLoad to be called for %d templates, got: %v", len(names), loaded)
+	}
+}
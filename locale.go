@@ -0,0 +1,260 @@
+package tmpl
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localeFormat is the number and date format of a language.
+type localeFormat struct {
+	decimal string
+	group   string
+
+	// date layouts by style
+	layouts map[string]string
+
+	// names replaces English month and day names
+	names *strings.Replacer
+}
+
+var (
+	englishMonths = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	englishDays   = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+)
+
+// newLocaleFormat returns a localeFormat with the date layouts for the short, medium, long and full styles
+// and the month, short month, day and short day names separated by spaces.
+func newLocaleFormat(decimal, group string, layouts [4]string, months, shortMonths, days, shortDays string) localeFormat {
+	f := localeFormat{
+		decimal: decimal,
+		group:   group,
+		layouts: map[string]string{"short": layouts[0], "medium": layouts[1], "long": layouts[2], "full": layouts[3]},
+	}
+	if months == "" {
+		return f
+	}
+	// long names are replaced before short names since short English names are prefixes of long names
+	var oldnew []string
+	for i, name := range strings.Fields(months) {
+		oldnew = append(oldnew, englishMonths[i], name)
+	}
+	for i, name := range strings.Fields(days) {
+		oldnew = append(oldnew, englishDays[i], name)
+	}
+	for i, name := range strings.Fields(shortMonths) {
+		oldnew = append(oldnew, englishMonths[i][:3], name)
+	}
+	for i, name := range strings.Fields(shortDays) {
+		oldnew = append(oldnew, englishDays[i][:3], name)
+	}
+	f.names = strings.NewReplacer(oldnew...)
+	return f
+}
+
+// localeFormats are the formats by language, languages without a format use English formats.
+var localeFormats = map[string]localeFormat{
+	"en": newLocaleFormat(".", ",",
+		[4]string{"1/2/06", "Jan 2, 2006", "January 2, 2006", "Monday, January 2, 2006"},
+		"", "", "", ""),
+	"de": newLocaleFormat(",", ".",
+		[4]string{"02.01.06", "02.01.2006", "2. January 2006", "Monday, 2. January 2006"},
+		"Januar Februar März April Mai Juni Juli August September Oktober November Dezember",
+		"Jan. Feb. März Apr. Mai Juni Juli Aug. Sept. Okt. Nov. Dez.",
+		"Sonntag Montag Dienstag Mittwoch Donnerstag Freitag Samstag",
+		"So. Mo. Di. Mi. Do. Fr. Sa."),
+	"fr": newLocaleFormat(",", "\u202f",
+		[4]string{"02/01/2006", "2 Jan 2006", "2 January 2006", "Monday 2 January 2006"},
+		"janvier février mars avril mai juin juillet août septembre octobre novembre décembre",
+		"janv. févr. mars avr. mai juin juil. août sept. oct. nov. déc.",
+		"dimanche lundi mardi mercredi jeudi vendredi samedi",
+		"dim. lun. mar. mer. jeu. ven. sam."),
+	"es": newLocaleFormat(",", ".",
+		[4]string{"2/1/06", "2 Jan 2006", "2 de January de 2006", "Monday, 2 de January de 2006"},
+		"enero febrero marzo abril mayo junio julio agosto septiembre octubre noviembre diciembre",
+		"ene feb mar abr may jun jul ago sept oct nov dic",
+		"domingo lunes martes miércoles jueves viernes sábado",
+		"dom lun mar mié jue vie sáb"),
+	"it": newLocaleFormat(",", ".",
+		[4]string{"02/01/06", "2 Jan 2006", "2 January 2006", "Monday 2 January 2006"},
+		"gennaio febbraio marzo aprile maggio giugno luglio agosto settembre ottobre novembre dicembre",
+		"gen feb mar apr mag giu lug ago set ott nov dic",
+		"domenica lunedì martedì mercoledì giovedì venerdì sabato",
+		"dom lun mar mer gio ven sab"),
+	"pt": newLocaleFormat(",", ".",
+		[4]string{"02/01/2006", "2 de Jan de 2006", "2 de January de 2006", "Monday, 2 de January de 2006"},
+		"janeiro fevereiro março abril maio junho julho agosto setembro outubro novembro dezembro",
+		"jan. fev. mar. abr. mai. jun. jul. ago. set. out. nov. dez.",
+		"domingo segunda-feira terça-feira quarta-feira quinta-feira sexta-feira sábado",
+		"dom. seg. ter. qua. qui. sex. sáb."),
+	"nl": newLocaleFormat(",", ".",
+		[4]string{"02-01-2006", "2 Jan 2006", "2 January 2006", "Monday 2 January 2006"},
+		"januari februari maart april mei juni juli augustus september oktober november december",
+		"jan feb mrt apr mei jun jul aug sep okt nov dec",
+		"zondag maandag dinsdag woensdag donderdag vrijdag zaterdag",
+		"zo ma di wo do vr za"),
+}
+
+// language returns the lowercase language of a locale, ie. "pt" for "pt-BR".
+func language(locale string) string {
+	lang, _, _ := strings.Cut(normalizeLocale(locale), "-")
+	return lang
+}
+
+// normalizeLocale returns the locale in lowercase with hyphen separators, ie. "pt-br" for "pt_BR".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// formatOf returns the format of the locale.
+func formatOf(locale string) localeFormat {
+	if f, ok := localeFormats[language(locale)]; ok {
+		return f
+	}
+	return localeFormats["en"]
+}
+
+// formatNumber formats a number with the decimal and group separators of the locale,
+// with decimals digits after the decimal separator or as many as needed if decimals is negative.
+func formatNumber(locale string, v any, decimals int) (string, error) {
+	var s string
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if decimals > 0 {
+			s = strconv.FormatFloat(float64(rv.Int()), 'f', decimals, 64)
+		} else {
+			s = strconv.FormatInt(rv.Int(), 10)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if decimals > 0 {
+			s = strconv.FormatFloat(float64(rv.Uint()), 'f', decimals, 64)
+		} else {
+			s = strconv.FormatUint(rv.Uint(), 10)
+		}
+	case reflect.Float32, reflect.Float64:
+		s = strconv.FormatFloat(rv.Float(), 'f', decimals, 64)
+	default:
+		return "", fmt.Errorf("expected number found %T", v)
+	}
+
+	f := formatOf(locale)
+	sign := ""
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = "-", rest
+	}
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			sb.WriteString(f.group)
+		}
+		sb.WriteRune(r)
+	}
+	if hasFraction {
+		sb.WriteString(f.decimal)
+		sb.WriteString(fraction)
+	}
+	return sb.String(), nil
+}
+
+// formatDate formats a time with the layout of the style in the locale, ie. "short", "medium", "long" or "full".
+// Any other style is used as the layout. English month and day names are replaced with the names of the locale.
+func formatDate(locale string, v any, style string) (string, error) {
+	var t time.Time
+	switch v := v.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return "", nil
+		}
+		t = *v
+	default:
+		return "", fmt.Errorf("expected time.Time found %T", v)
+	}
+	f := formatOf(locale)
+	layout, ok := f.layouts[style]
+	if !ok {
+		layout = style
+	}
+	s := t.Format(layout)
+	if f.names != nil {
+		s = f.names.Replace(s)
+	}
+	return s, nil
+}
+
+// pluralCategory returns the CLDR plural category of the integer n in the language of the locale.
+func pluralCategory(locale string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	switch language(locale) {
+	case "ja", "zh", "ko", "th", "vi", "id", "ms", "lo", "my", "km":
+		return "other"
+	case "fr", "hi", "fa", "bn", "pt":
+		if n == 0 || n == 1 {
+			return "one"
+		}
+	case "ru", "uk", "be":
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		}
+		return "many"
+	case "pl":
+		switch {
+		case n == 1:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		}
+		return "many"
+	case "cs", "sk":
+		switch {
+		case n == 1:
+			return "one"
+		case n >= 2 && n <= 4:
+			return "few"
+		}
+	case "ar":
+		switch {
+		case n == 0:
+			return "zero"
+		case n == 1:
+			return "one"
+		case n == 2:
+			return "two"
+		case n%100 >= 3 && n%100 <= 10:
+			return "few"
+		case n%100 >= 11:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+	}
+	return "other"
+}
+
+// toCount returns the integer count of a number for plural rules.
+func toCount(v any) (int, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return int(math.Trunc(rv.Float())), nil
+	}
+	return 0, fmt.Errorf("expected number found %T", v)
+}
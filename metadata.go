@@ -0,0 +1,87 @@
+package tmpl
+
+import (
+	"html/template"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Metadata describes the source of a loaded or autoloaded template.
+type Metadata struct {
+	// Name is the template name.
+	Name string
+
+	// Files are the source files parsed into the template in order,
+	// the last file is the template file.
+	Files []File
+
+	// Layouts are the names of the layout templates parsed into the template,
+	// from the outermost layout to the innermost layout.
+	Layouts []string
+
+	// Ext is the file extension of the template file.
+	Ext string
+
+	// Autoloaded reports whether the template was autoloaded to the root template
+	// and is available as an associated template in all templates.
+	Autoloaded bool
+
+	// Meta is the frontmatter of all files merged in order,
+	// so the frontmatter of the template file overrides the frontmatter of it's layouts.
+	Meta map[string]any
+}
+
+// File is a template source file.
+type File struct {
+	// Name is the template name of the file, which is the filepath without the extension.
+	Name string
+
+	// Path is the filepath of the file.
+	Path string
+
+	// Ext is the file extension of the file.
+	Ext string
+
+	// Layer is the index of the filesystem layer the file was loaded from when using Overlay.
+	// The filesystem passed to New is layer 0.
+	Layer int
+
+	// Meta is the YAML or TOML frontmatter of the file.
+	Meta map[string]any
+}
+
+// Lookup returns the loaded template with the given name or nil if there is no such template.
+//
+// Autoloaded templates are associated templates of the root template named "<root>".
+// A lazy template is parsed first, Lookup returns nil if it fails to parse.
+func (t Templates) Lookup(name string) *template.Template {
+	tmpl, _ := t.lookup(name)
+	return tmpl
+}
+
+// Metadata returns the metadata of the loaded or autoloaded template with the given name.
+// A lazy template is parsed first, Metadata reports false if it fails to parse.
+func (t Templates) Metadata(name string) (Metadata, bool) {
+	if l, ok := t.lazy[name]; ok {
+		_, meta, err := l.load(t.lazyRoot)
+		return meta, err == nil
+	}
+	meta, ok := t.metadata[name]
+	return meta, ok
+}
+
+// Entries returns the metadata of all loaded and autoloaded templates sorted by name.
+// Lazy templates are parsed first and omitted if they fail to parse.
+func (t Templates) Entries() []Metadata {
+	entries := slices.Collect(maps.Values(t.metadata))
+	for _, l := range t.lazy {
+		if _, meta, err := l.load(t.lazyRoot); err == nil {
+			entries = append(entries, meta)
+		}
+	}
+	slices.SortFunc(entries, func(a, b Metadata) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return entries
+}
@@ -0,0 +1,55 @@
+package tmpl
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMetadata(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/button.html": {},
+		"pages/layout.html":      {},
+		"pages/sub/layout.html":  {},
+		"pages/sub/index.html":   {},
+		"pages/about.html":       {},
+	}
+	templates := New(fs).Autoload("components").LoadTree("pages").Load("pages/about").MustParse()
+
+	var names []string
+	for _, meta := range templates.Entries() {
+		names = append(names, meta.Name)
+	}
+	if expected := []string{"components/button", "pages/about", "pages/sub/index"}; !slices.Equal(expected, names) {
+		t.Errorf("expected: %v, got: %v", expected, names)
+	}
+
+	meta, ok := templates.Metadata("pages/sub/index")
+	if !ok {
+		t.Fatal("expected metadata for pages/sub/index")
+	}
+	expectedFiles := []File{
+		{Name: "pages/layout", Path: "pages/layout.html", Ext: "html"},
+		{Name: "pages/sub/layout", Path: "pages/sub/layout.html", Ext: "html"},
+		{Name: "pages/sub/index", Path: "pages/sub/index.html", Ext: "html"},
+	}
+	if !slices.EqualFunc(expectedFiles, meta.Files, func(a, b File) bool {
+		return a.Name == b.Name && a.Path == b.Path && a.Ext == b.Ext
+	}) {
+		t.Errorf("expected: %v, got: %v", expectedFiles, meta.Files)
+	}
+	if expected := []string{"pages/layout", "pages/sub/layout"}; !slices.Equal(expected, meta.Layouts) {
+		t.Errorf("expected: %v, got: %v", expected, meta.Layouts)
+	}
+	if meta.Ext != "html" || meta.Autoloaded {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+
+	meta, _ = templates.Metadata("components/button")
+	if !meta.Autoloaded {
+		t.Errorf("expected components/button to be autoloaded")
+	}
+	if templates.Lookup("components/button") != nil || templates.Lookup("pages/about") == nil {
+		t.Errorf("expected only loaded templates to be looked up")
+	}
+}
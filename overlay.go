@@ -0,0 +1,74 @@
+package tmpl
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+)
+
+// overlayFS merges layers of filesystems, files in later layers shadow files in earlier layers with the same path.
+// Directories are merged and contain the entries of the directory in all layers.
+type overlayFS []fs.FS
+
+// Open opens the named file from the last layer that contains it.
+func (o overlayFS) Open(name string) (fs.File, error) {
+	var errs []error
+	for i := len(o) - 1; i >= 0; i-- {
+		file, err := o[i].Open(name)
+		if err == nil {
+			return file, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(o) == 0 {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	// report the error of the last layer
+	return nil, errs[0]
+}
+
+// ReadDir reads the named directory from all layers that contain it
+// and returns the merged directory entries sorted by filename.
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	var errs []error
+	seen := make(map[string]bool)
+	found := false
+	for i := len(o) - 1; i >= 0; i-- {
+		layerEntries, err := fs.ReadDir(o[i], name)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		found = true
+		for _, entry := range layerEntries {
+			if !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return entries, errors.Join(errs...)
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
+// layer returns the index of the last layer that contains the named file.
+func (o overlayFS) layer(name string) int {
+	for i := len(o) - 1; i >= 0; i-- {
+		if _, err := fs.Stat(o[i], name); err == nil {
+			return i
+		}
+	}
+	return 0
+}
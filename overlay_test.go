@@ -0,0 +1,82 @@
+package tmpl
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOverlay(t *testing.T) {
+	base := fstest.MapFS{
+		"components/button.html": {
+			Data: []byte(`<button>Base</button>`),
+		},
+		"components/input.html": {
+			Data: []byte(`<input>`),
+		},
+		"pages/layout.html": {
+			Data: []byte(`<main>{{ slot .Children }}</main>`),
+		},
+		"pages/index.html": {
+			Data: []byte(`{{ template "components/button" }}{{ template "components/input" }}`),
+		},
+	}
+	theme := fstest.MapFS{
+		"components/button.html": {
+			Data: []byte(`<button>Theme</button>`),
+		},
+		"pages/layout.html": {
+			Data: []byte(`<body>{{ slot .Children }}</body>`),
+		},
+	}
+	custom := fstest.MapFS{
+		"components/button.html": {
+			Data: []byte(`<button>Custom</button>`),
+		},
+		"pages/about.html": {
+			Data: []byte(`<p>About</p>`),
+		},
+	}
+	templates := New(base).Overlay(theme, custom).Autoload("components").LoadTree("pages").MustParse()
+
+	buf := new(bytes.Buffer)
+	tests := []struct {
+		template Template
+		expected string
+	}{
+		{
+			template: Tmpl("pages/index", nil),
+			expected: "<button>Custom</button><input>",
+		},
+		{
+			template: Associated("pages/about", "pages/layout", Map{"Children": Tmpl("pages/about", nil)}),
+			expected: "<body><p>About</p></body>",
+		},
+	}
+	for _, test := range tests {
+		err := templates.Render(buf, test.template)
+		if err != nil {
+			t.Error(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("expected: %q, got: %q", test.expected, buf.String())
+		}
+		buf.Reset()
+	}
+
+	layers := map[string]int{
+		"components/button": 2,
+		"components/input":  0,
+		"pages/about":       2,
+	}
+	for name, layer := range layers {
+		meta, _ := templates.Metadata(name)
+		if got := meta.Files[len(meta.Files)-1].Layer; got != layer {
+			t.Errorf("%s: expected layer: %d, got: %d", name, layer, got)
+		}
+	}
+	meta, _ := templates.Metadata("pages/index")
+	if layer := meta.Files[0].Layer; layer != 1 {
+		t.Errorf("pages/layout: expected layer: 1, got: %d", layer)
+	}
+}
@@ -0,0 +1,157 @@
+package tmpl
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"maps"
+	"sync"
+	"text/template/parse"
+)
+
+// renderContext is the request-scoped data of a render.
+type renderContext struct {
+	ctx    context.Context
+	values map[string]any
+}
+
+// ctxFunc returns the ctx func which returns request-scoped data of the render context rc.
+//
+//	ctx       returns the context.Context or context.Background if the render has no context
+//	ctx key   returns the value of key in the render values or the context value of key
+func ctxFunc(rc *renderContext) any {
+	return func(key ...any) (any, error) {
+		if len(key) > 1 {
+			return nil, fmt.Errorf("expected key found %d arguments", len(key))
+		}
+		if len(key) == 0 {
+			if rc == nil || rc.ctx == nil {
+				return context.Background(), nil
+			}
+			return rc.ctx, nil
+		}
+		if rc == nil {
+			return nil, nil
+		}
+		if k, ok := key[0].(string); ok {
+			if v, ok := rc.values[k]; ok {
+				return v, nil
+			}
+		}
+		if rc.ctx != nil {
+			return rc.ctx.Value(key[0]), nil
+		}
+		return nil, nil
+	}
+}
+
+// contextPool pools clones of a template that uses the ctx func, each clone has it's own render context.
+// Clones are made from a master copy that is never executed since templates cannot be cloned after they are executed.
+type contextPool struct {
+	master     *template.Template
+	components components
+	pool       sync.Pool
+}
+
+// contextInstance is a clone of a template bound to a render context.
+type contextInstance struct {
+	tmpl *template.Template
+	rc   *renderContext
+}
+
+// newContextPool returns a contextPool for tmpl or nil if none of it's templates use the ctx func.
+// newContextPool must be called before tmpl is executed.
+func newContextPool(tmpl *template.Template, components components) (*contextPool, error) {
+	if !usesCtx(tmpl) {
+		return nil, nil
+	}
+	master, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return &contextPool{master: master, components: components}, nil
+}
+
+// get returns an unused clone of the template.
+func (p *contextPool) get() (*contextInstance, error) {
+	if inst, ok := p.pool.Get().(*contextInstance); ok {
+		return inst, nil
+	}
+	clone, err := p.master.Clone()
+	if err != nil {
+		return nil, err
+	}
+	rc := new(renderContext)
+	clone.Funcs(contextFuncMap(clone, p.components)).Funcs(template.FuncMap{"ctx": ctxFunc(rc)})
+	return &contextInstance{clone, rc}, nil
+}
+
+// put clears the render context of the clone and returns it to the pool.
+func (p *contextPool) put(inst *contextInstance) {
+	*inst.rc = renderContext{}
+	p.pool.Put(inst)
+}
+
+// usesCtx reports whether any template associated with tmpl calls the ctx func.
+func usesCtx(tmpl *template.Template) bool {
+	for _, assoc := range tmpl.Templates() {
+		if assoc.Tree == nil || assoc.Tree.Root == nil {
+			continue
+		}
+		found := false
+		walkTree(assoc.Tree.Root, func(node parse.Node) {
+			if ident, ok := node.(*parse.IdentifierNode); ok && ident.Ident == "ctx" {
+				found = true
+			}
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// withContexts returns templates with a contextPool for every loaded template that uses the ctx func.
+func (t *templatesParser) withContexts(templates Templates) (Templates, error) {
+	templates.contexts = make(map[string]*contextPool)
+	for name, tmpl := range templates.templates {
+		p, err := newContextPool(tmpl, t.components)
+		if err != nil {
+			return Templates{}, err
+		}
+		if p != nil {
+			templates.contexts[name] = p
+		}
+	}
+	return templates, nil
+}
+
+// contextPool returns the contextPool of the loaded template name or nil if it does not use the ctx func.
+func (t Templates) contextPool(name string) *contextPool {
+	if l, ok := t.lazy[name]; ok {
+		l.load(t.lazyRoot)
+		return l.context
+	}
+	return t.contexts[name]
+}
+
+// WithContext returns templates that render with ctx.
+//
+// In templates ctx returns the context and ctx "key" returns the context value of key,
+// ie. {{ ctx "user" }} or {{ .Posts.List ctx }}. The context is available in slots, components and streamed templates.
+func (t Templates) WithContext(ctx context.Context) Templates {
+	t.ctx = ctx
+	return t
+}
+
+// WithValues returns templates that render with the values added to any previous values.
+//
+// In templates ctx "key" returns the value of key, values take precedence over context values.
+// This is useful for request-scoped data like the current user, CSRF token or request URL.
+func (t Templates) WithValues(values map[string]any) Templates {
+	merged := make(map[string]any, len(t.values)+len(values))
+	maps.Copy(merged, t.values)
+	maps.Copy(merged, values)
+	t.values = merged
+	return t
+}
@@ -0,0 +1,117 @@
+package tmpl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type userKey struct{}
+
+type contextPage struct {
+	Key  any
+	Data AsyncValue[string, string]
+}
+
+func (p contextPage) Tmpl() Template {
+	return Tmpl("pages/stream", p)
+}
+
+func TestRenderContext(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/card.html":  {Data: []byte(`<div>{{ ctx "csrf" }}|{{ slot .children }}</div>`)},
+		"components/plain.html": {Data: []byte(`<p>{{ . }}</p>`)},
+		"pages/index.html":      {Data: []byte(`{{ ctx "user" }}|{{ ctx .Key }}|{{ template "components/card" (map "children" (tmpl "pages/slot" .)) }}{{ define "pages/slot" }}{{ ctx "user" }}{{ end }}`)},
+		"pages/stream.html":     {Data: []byte(`<main>{{ stream "pages/stream:data" .Data }}</main>{{ define "pages/stream:data" }}{{ . }} {{ ctx "user" }}{{ end }}`)},
+	}
+
+	for _, lazy := range []bool{false, true} {
+		templates := New(fs).Lazy(lazy).Autoload("components").LoadTree("pages").MustParse()
+		data := map[string]any{"Key": userKey{}}
+
+		// without a render context ctx returns nothing
+		var sb strings.Builder
+		if err := templates.Render(&sb, Tmpl("pages/index", data)); err != nil {
+			t.Fatal(err)
+		}
+		if expected := "||<div>|</div>"; sb.String() != expected {
+			t.Errorf("lazy: %v: expected: %q, got: %q", lazy, expected, sb.String())
+		}
+
+		// concurrent renders use their own render context
+		var wg sync.WaitGroup
+		results := make([]string, 10)
+		errs := make([]error, 10)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := context.WithValue(context.Background(), userKey{}, fmt.Sprint("ctx", i))
+				var sb strings.Builder
+				errs[i] = templates.
+					WithContext(ctx).
+					WithValues(map[string]any{"user": fmt.Sprint("user", i)}).
+					WithValues(map[string]any{"csrf": fmt.Sprint("csrf", i)}).
+					Render(&sb, Tmpl("pages/index", data))
+				results[i] = sb.String()
+			}()
+		}
+		wg.Wait()
+		for i, result := range results {
+			if errs[i] != nil {
+				t.Fatal(errs[i])
+			}
+			if expected := fmt.Sprintf("user%d|ctx%d|<div>csrf%d|user%d</div>", i, i, i, i); result != expected {
+				t.Errorf("lazy: %v: expected: %q, got: %q", lazy, expected, result)
+			}
+		}
+
+		// autoloaded templates without ctx render as is
+		sb.Reset()
+		if err := templates.WithValues(map[string]any{"user": "x"}).Render(&sb, Tmpl("components/plain", "ok")); err != nil {
+			t.Fatal(err)
+		}
+		if expected := "<p>ok</p>"; sb.String() != expected {
+			t.Errorf("expected: %q, got: %q", expected, sb.String())
+		}
+
+		// streamed templates render with the render context
+		r := templates.WithValues(map[string]any{"user": "ana"}).StreamRenderer()
+		page := contextPage{Data: NewAsyncValue[string, string](r)}
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			page.Data.Ok("hello")
+		}()
+		sb.Reset()
+		if err := r.Render(&sb, page); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(sb.String(), "hello ana") {
+			t.Errorf("lazy: %v: expected streamed content with render context, got: %q", lazy, sb.String())
+		}
+	}
+}
+
+func TestCtxFunc(t *testing.T) {
+	ctx := context.WithValue(context.Background(), userKey{}, "ctx")
+	fn := ctxFunc(&renderContext{ctx: ctx, values: map[string]any{"user": "value"}}).(func(...any) (any, error))
+	if v, _ := fn(); v != ctx {
+		t.Errorf("expected ctx to return the context, got: %v", v)
+	}
+	if v, _ := fn("user"); v != "value" {
+		t.Errorf("expected: %q, got: %v", "value", v)
+	}
+	if v, _ := fn(userKey{}); v != "ctx" {
+		t.Errorf("expected: %q, got: %v", "ctx", v)
+	}
+	if _, err := fn("a", "b"); err == nil || err.Error() != "expected key found 2 arguments" {
+		t.Errorf("expected arguments error, got: %v", err)
+	}
+	if v, _ := ctxFunc(nil).(func(...any) (any, error))(); v != context.Background() {
+		t.Errorf("expected background context without a render context, got: %v", v)
+	}
+}
@@ -32,15 +32,44 @@ func (t Templates) Render(w io.Writer, tp Template) error {
 	return t.SyncRenderer().Render(w, tp)
 }
 
+// RenderError renders the nearest error template of the loaded template name with err as data.
+//
+// Render does not render error templates when a template fails to render, as part of the output may already be written to w.
+// Use RenderError to render the error template instead of the page, ie. when loading the page data fails or after buffering the page.
+// RenderError returns err if the template has no error template.
+func (t Templates) RenderError(w io.Writer, name string, err error) error {
+	tmpl, lerr := t.lookup(name)
+	if lerr != nil || tmpl == nil || tmpl.Lookup(ErrorTemplate) == nil {
+		return err
+	}
+	return t.Render(w, Associated(name, ErrorTemplate, err))
+}
+
 func (r *renderer) Render(w io.Writer, tp Template) error {
 	base, name, data := Info(tp)
-	t := r.Templates[base]
+	t, err := r.lookup(base)
+	if err != nil {
+		return err
+	}
 	if t == nil {
-		t = r.Templates["<root>"]
+		base = "<root>"
+		t = r.Lookup(base)
+	}
+	// render with a clone of the template bound to the render context
+	if r.ctx != nil || r.values != nil {
+		if p := r.contextPool(base); p != nil {
+			inst, err := p.get()
+			if err != nil {
+				return err
+			}
+			defer p.put(inst)
+			inst.rc.ctx, inst.rc.values = r.ctx, r.values
+			t = inst.tmpl
+		}
 	}
 	// attach writer to renderer
 	r.w = w
-	err := t.ExecuteTemplate(w, name, data)
+	err = t.ExecuteTemplate(w, name, data)
 	if err != nil || r.stream == nil {
 		return err
 	}
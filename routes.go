@@ -0,0 +1,276 @@
+package tmpl
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Route maps a URL pattern to the name of a template loaded with LoadTree.
+type Route struct {
+	// Pattern is the URL path pattern using http.ServeMux wildcards, ie. "/users/{id}".
+	Pattern string
+
+	// Name is the template name, ie. "pages/users/[id]/index".
+	Name string
+
+	// Params are the names of the dynamic segments in the order they appear.
+	Params []string
+}
+
+// Routes is a route table ordered by precedence,
+// static segments are matched before dynamic segments and dynamic segments are matched before catch-all segments.
+type Routes []Route
+
+// Routes returns the route table for the templates in dir, usually the directory loaded with LoadTree.
+//
+// The route pattern is derived from the template name relative to dir.
+// Directories and files named [name] are dynamic segments and [...name] are catch-all segments.
+// Index templates are served at the directory path and special templates are not routed.
+//
+// For instance, with dir "pages" the template named:
+//
+//	pages/index               -> /{$}
+//	pages/profile/index       -> /profile
+//	pages/users/[id]/index    -> /users/{id}
+//	pages/docs/[...slug]      -> /docs/{slug...}
+//
+// The patterns can be registered on http.ServeMux and the dynamic segments read with Request.PathValue.
+func (t Templates) Routes(dir string) Routes {
+	dir = strings.TrimSuffix(dir, "/")
+	var routes Routes
+	for _, name := range t.names() {
+		if name == "<root>" || !isWithin(name, dir) || name == dir || isSpecial(name) {
+			continue
+		}
+		routes = append(routes, newRoute(name, relativeName(name, dir)))
+	}
+	slices.SortFunc(routes, compareRoutes)
+	return routes
+}
+
+// NotFound returns the name of the nearest not-found template in dir for the URL path.
+//
+// A not-found template matches any path in it's directory, the deepest matching not-found template is returned.
+// NotFound reports false if no not-found template in dir matches path.
+func (t Templates) NotFound(dir, path string) (string, bool) {
+	dir = strings.TrimSuffix(dir, "/")
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	var nearest Route
+	for _, name := range t.names() {
+		if name == "<root>" || !isWithin(name, dir) {
+			continue
+		}
+		rel := relativeName(name, dir)
+		parent, filename := filepath.Split(rel)
+		if specialFilenames[filename] != NotFoundTemplate {
+			continue
+		}
+		// not-found templates in group directories match the paths of the routes in the group
+		route := newRoute(name, withoutGroups(parent+"index"))
+		pattern := patternSegments(route.Pattern)
+		if len(pattern) > len(segments) {
+			continue
+		}
+		if _, ok := matchSegments(pattern, segments[:len(pattern)]); !ok {
+			continue
+		}
+		if nearest.Name == "" || len(pattern) > len(patternSegments(nearest.Pattern)) ||
+			(len(pattern) == len(patternSegments(nearest.Pattern)) && compareRoutes(route, nearest) < 0) {
+			nearest = route
+		}
+	}
+	return nearest.Name, nearest.Name != ""
+}
+
+// routeErrors returns a load error for each template in the file groups of dir
+// with a route pattern that conflicts with the route of another template when both are registered on http.ServeMux,
+// ie. pages/about and pages/about/index or pages/users/[id]/edit and pages/users/new/[x].
+func (t *templatesParser) routeErrors(dir string, groups map[string][]string) error {
+	dir = strings.TrimSuffix(dir, "/")
+	mux := http.NewServeMux()
+	var routes []Route
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		if name == dir || isSpecial(name) {
+			continue
+		}
+		route := newRoute(name, relativeName(name, dir))
+		if err := handleRoute(mux, route.Pattern); err != nil {
+			// find the conflicting route to report it's template name
+			for _, prev := range routes {
+				if handleRoute(http.NewServeMux(), prev.Pattern, route.Pattern) != nil {
+					err = fmt.Errorf("route %s conflicts with %s", route.Pattern, prev.Name)
+					break
+				}
+			}
+			file := groups[name][len(groups[name])-1]
+			errs = append(errs, newLoadError(name, t.filePath(file), err))
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return errors.Join(errs...)
+}
+
+// handleRoute registers the patterns on mux and returns the error of a pattern that cannot be registered.
+func handleRoute(mux *http.ServeMux, patterns ...string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	for _, pattern := range patterns {
+		mux.Handle(pattern, http.NotFoundHandler())
+	}
+	return nil
+}
+
+// relativeName returns the template name relative to dir.
+func relativeName(name, dir string) string {
+	if dir == "." {
+		return name
+	}
+	return strings.TrimPrefix(name, dir+"/")
+}
+
+// isSpecial reports whether the template name is a special template.
+func isSpecial(name string) bool {
+	_, filename := filepath.Split(name)
+	_, ok := specialFilenames[filename]
+	return ok
+}
+
+// newRoute returns the route for the template name with the path rel.
+func newRoute(name, rel string) Route {
+	segments := strings.Split(rel, "/")
+	if segments[len(segments)-1] == "index" {
+		segments = segments[:len(segments)-1]
+	}
+	route := Route{Name: name}
+	if len(segments) == 0 {
+		route.Pattern = "/{$}"
+		return route
+	}
+	for i, segment := range segments {
+		param, catchAll := dynamicSegment(segment)
+		if param == "" {
+			continue
+		}
+		route.Params = append(route.Params, param)
+		if catchAll {
+			segments[i] = "{" + param + "...}"
+		} else {
+			segments[i] = "{" + param + "}"
+		}
+	}
+	route.Pattern = "/" + strings.Join(segments, "/")
+	return route
+}
+
+// dynamicSegment returns the param name of a [name] or [...name] path segment and whether it is a catch-all segment.
+// The param name is empty for static segments.
+func dynamicSegment(segment string) (param string, catchAll bool) {
+	if len(segment) < 3 || segment[0] != '[' || segment[len(segment)-1] != ']' {
+		return "", false
+	}
+	param = segment[1 : len(segment)-1]
+	if rest, ok := strings.CutPrefix(param, "..."); ok {
+		return rest, true
+	}
+	return param, false
+}
+
+// segmentRank orders pattern segments by precedence.
+func segmentRank(segment string) int {
+	switch {
+	case strings.HasSuffix(segment, "...}"):
+		return 2
+	case strings.HasPrefix(segment, "{"):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareRoutes(a, b Route) int {
+	as, bs := patternSegments(a.Pattern), patternSegments(b.Pattern)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if r := segmentRank(as[i]) - segmentRank(bs[i]); r != 0 {
+			return r
+		}
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// patternSegments returns the path segments of a route pattern.
+func patternSegments(pattern string) []string {
+	if pattern == "/{$}" {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+}
+
+// Lookup returns the route for the template name.
+func (r Routes) Lookup(name string) (Route, bool) {
+	for _, route := range r {
+		if route.Name == name {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// Match returns the first route that matches the URL path and the values of it's dynamic segments.
+func (r Routes) Match(path string) (Route, map[string]string, bool) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if path == "/" {
+		segments = nil
+	}
+	for _, route := range r {
+		if params, ok := matchSegments(patternSegments(route.Pattern), segments); ok {
+			return route, params, true
+		}
+	}
+	return Route{}, nil, false
+}
+
+func matchSegments(pattern, segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, p := range pattern {
+		if i >= len(segments) {
+			return nil, false
+		}
+		switch segmentRank(p) {
+		case 2:
+			value, err := url.PathUnescape(strings.Join(segments[i:], "/"))
+			if err != nil {
+				return nil, false
+			}
+			params[strings.TrimSuffix(p[1:], "...}")] = value
+			return params, true
+		case 1:
+			value, err := url.PathUnescape(segments[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			params[p[1:len(p)-1]] = value
+		default:
+			if p != segments[i] {
+				return nil, false
+			}
+		}
+	}
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	return params, true
+}
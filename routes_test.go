@@ -0,0 +1,129 @@
+package tmpl
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRoutes(t *testing.T) {
+	fs := fstest.MapFS{
+		"pages/layout.html":                 {},
+		"pages/index.html":                  {},
+		"pages/profile/index.html":          {},
+		"pages/users/new.html":              {},
+		"pages/users/[id]/index.html":       {},
+		"pages/users/[id]/posts/[pid].html": {},
+		"pages/docs/[...slug]/index.html":   {},
+		"pages/not-found.html":              {},
+		"pages/users/[id]/not-found.html":   {},
+		"pages/users/[id]/error.html":       {},
+		"components/button.html":            {},
+	}
+	templates := New(fs).Autoload("components").LoadTree("pages").MustParse()
+	routes := templates.Routes("pages")
+
+	expected := Routes{
+		{Pattern: "/{$}", Name: "pages/index"},
+		{Pattern: "/docs/{slug...}", Name: "pages/docs/[...slug]/index", Params: []string{"slug"}},
+		{Pattern: "/profile", Name: "pages/profile/index"},
+		{Pattern: "/users/new", Name: "pages/users/new"},
+		{Pattern: "/users/{id}", Name: "pages/users/[id]/index", Params: []string{"id"}},
+		{Pattern: "/users/{id}/posts/{pid}", Name: "pages/users/[id]/posts/[pid]", Params: []string{"id", "pid"}},
+	}
+	if !slices.EqualFunc(expected, routes, func(a, b Route) bool {
+		return a.Pattern == b.Pattern && a.Name == b.Name && slices.Equal(a.Params, b.Params)
+	}) {
+		t.Errorf("expected: %v, got: %v", expected, routes)
+	}
+
+	tests := []struct {
+		path   string
+		name   string
+		params map[string]string
+	}{
+		{"/", "pages/index", map[string]string{}},
+		{"/profile", "pages/profile/index", map[string]string{}},
+		{"/users/new", "pages/users/new", map[string]string{}},
+		{"/users/42", "pages/users/[id]/index", map[string]string{"id": "42"}},
+		{"/users/a%20b/posts/1", "pages/users/[id]/posts/[pid]", map[string]string{"id": "a b", "pid": "1"}},
+		{"/docs/guide/intro", "pages/docs/[...slug]/index", map[string]string{"slug": "guide/intro"}},
+		{"/users", "", nil},
+		{"/profile/edit", "", nil},
+	}
+	for _, test := range tests {
+		route, params, ok := routes.Match(test.path)
+		if ok != (test.name != "") {
+			t.Errorf("%s: expected match %v", test.path, !ok)
+			continue
+		}
+		if route.Name != test.name || !maps.Equal(params, test.params) {
+			t.Errorf("%s: expected: %s %v, got: %s %v", test.path, test.name, test.params, route.Name, params)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	fs := fstest.MapFS{
+		"pages/index.html":                 {},
+		"pages/not-found.html":             {},
+		"pages/users/[id]/index.html":      {},
+		"pages/users/[id]/not-found.html":  {},
+		"pages/users/new/not-found.html":   {},
+		"pages/(shop)/cart/index.html":     {},
+		"pages/(shop)/cart/not-found.html": {},
+	}
+	templates := New(fs).LoadTree("pages").MustParse()
+	tests := []struct {
+		path string
+		name string
+	}{
+		{"/missing", "pages/not-found"},
+		{"/users", "pages/not-found"},
+		{"/users/42/missing", "pages/users/[id]/not-found"},
+		{"/users/new/missing", "pages/users/new/not-found"},
+		{"/cart/missing", "pages/(shop)/cart/not-found"},
+	}
+	for _, test := range tests {
+		name, ok := templates.NotFound("pages", test.path)
+		if !ok || name != test.name {
+			t.Errorf("%s: expected: %s, got: %s", test.path, test.name, name)
+		}
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	fs := fstest.MapFS{
+		"pages/about.html":                {},
+		"pages/about/index.html":          {},
+		"pages/users/[id].html":           {},
+		"pages/users/[slug]/index.html":   {},
+		"pages/users/new.html":            {},
+		"pages/users/[id]/edit.html":      {},
+		"pages/users/new/[tab].html":      {},
+		"pages/docs/[...path].html":       {},
+		"pages/docs/[...slug]/index.html": {},
+		"pages/not-found.html":            {},
+		"pages/(group)/not-found.html":    {},
+	}
+	// routes are only checked with CheckRoutes
+	if _, err := New(fs).LoadTree("pages").Parse(); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	_, err := New(fs).CheckRoutes(true).CollectErrors(true).LoadTree("pages").Parse()
+	var errs []string
+	for _, err := range flattenErrors(err) {
+		errs = append(errs, err.Error())
+	}
+	expected := []string{
+		"pages/about/index.html: route /about conflicts with pages/about",
+		"pages/docs/[...slug]/index.html: route /docs/{slug...} conflicts with pages/docs/[...path]",
+		"pages/users/[slug]/index.html: route /users/{slug} conflicts with pages/users/[id]",
+		"pages/users/new/[tab].html: route /users/new/{tab} conflicts with pages/users/[id]/edit",
+	}
+	if !slices.Equal(expected, errs) {
+		t.Errorf("expected: %q, got: %q", expected, errs)
+	}
+}
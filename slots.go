@@ -0,0 +1,54 @@
+package tmpl
+
+import (
+	"html/template"
+	"reflect"
+)
+
+// Slots maps slot names to slotted content, slotted content can be a Template or string.
+//
+// Embed Slots in a layout template struct alongside Children to render several regions of a layout,
+// ie. {{ slotNamed "sidebar" . }} renders the slotted content named sidebar.
+type Slots map[string]any
+
+// Slot returns the slotted content with the given name.
+func (s Slots) Slot(name string) any { return s[name] }
+
+// slotter is implemented by template data with named slots.
+type slotter interface {
+	Slot(name string) any
+}
+
+// namedSlots returns the slotted content named name in data and reports whether data has named slots.
+// Data has named slots if it implements a Slot method or is a map with string keys.
+func namedSlots(data any, name string) (any, bool) {
+	if s, ok := data.(slotter); ok {
+		return s.Slot(name), true
+	}
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+	if !value.IsValid() {
+		return nil, true
+	}
+	return value.Interface(), true
+}
+
+// emptySlot reports whether the slotted content is empty and should render the fallback content.
+func emptySlot(data any) bool {
+	switch data := data.(type) {
+	case nil:
+		return true
+	case string:
+		return data == ""
+	case template.HTML:
+		return data == ""
+	case Children:
+		return data.Template == nil
+	case *Children:
+		return data == nil || data.Template == nil
+	}
+	return false
+}
@@ -62,11 +62,19 @@ func stream(t *template.Template, name string, av asyncValuer) (template.HTML, e
 	return renderStream(t, r.stream, name, av)
 }
 
+// fallbackName returns name if it is defined in t, otherwise fallback if it is defined in t.
+func fallbackName(t *template.Template, name, fallback string) string {
+	if t.Lookup(name) == nil && t.Lookup(fallback) != nil {
+		return fallback
+	}
+	return name
+}
+
 func renderSync(t *template.Template, name string, d streamData) (template.HTML, error) {
 	html := new(strings.Builder)
-	// if not ok render error template instead
+	// if not ok render error template or nearest error template instead
 	if !d.ok {
-		name += ":error"
+		name = fallbackName(t, name+":error", ErrorTemplate)
 	}
 	err := t.ExecuteTemplate(html, name, d.data)
 	if err != nil && !d.ok {
@@ -90,9 +98,9 @@ func renderStream(t *template.Template, stream *streamController, name string, a
 		go func() {
 			stream.ch <- streamTp{av.get(), name, cid}
 		}()
-		// immediately render pending template or empty slot if no pending template
+		// immediately render pending template, nearest loading template or empty slot if no pending template
 		html := new(strings.Builder)
-		if err := t.ExecuteTemplate(html, name+":pending", nil); err != nil {
+		if err := t.ExecuteTemplate(html, fallbackName(t, name+":pending", LoadingTemplate), nil); err != nil {
 			return pendingHTML(cid, ""), nil
 		}
 		return pendingHTML(cid, html.String()), nil
@@ -124,9 +132,9 @@ func awaitStream(w io.Writer, t *template.Template, stream *streamController) er
 				stream.wg.Done()
 				continue
 			}
-			// if not ok render error template instead
+			// if not ok render error template or nearest error template instead
 			if !streamTp.ok {
-				streamTp.name += ":error"
+				streamTp.name = fallbackName(t, streamTp.name+":error", ErrorTemplate)
 			}
 			// render template for each template data received on channel
 			html := new(strings.Builder)
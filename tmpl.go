@@ -1,8 +1,13 @@
 package tmpl
 
 import (
+	"context"
+	"errors"
 	"html/template"
 	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
 )
 
 // Template is implemented by any value that has a Tmpl method which returns a template definition.
@@ -64,40 +69,101 @@ func Wrap(parent Layout, t Template) Template {
 	return parent
 }
 
+// Associated template names of the nearest special templates of a template loaded with LoadTree.
+//
+// Files named error, loading and not-found are special templates, they are loaded as regular templates
+// and the nearest of each kind in the same or a parent directory is associated to every template in that directory.
+// Only the top-level template of a special file is associated, the templates it defines are not.
+const (
+	// ErrorTemplate renders errors, it is rendered by Templates.RenderError and when a streamed template has no error template.
+	ErrorTemplate = ":error"
+
+	// LoadingTemplate is rendered when a streamed template has no pending template.
+	LoadingTemplate = ":loading"
+
+	// NotFoundTemplate renders not found pages.
+	NotFoundTemplate = ":not-found"
+)
+
 type Map map[string]any
 
-// Templates stores all loaded templates.
+// Templates stores all loaded templates and their metadata.
 //
-// When rendering a Template, the template name is used to index this map
+// When rendering a Template, the template name is used to lookup the loaded template
 // and the returned template is executed with the template name and data.
 //
-// Use Base to set the base template name that will be used to index the map.
+// Use Base to set the base template name that will be used to lookup the loaded template.
 // This is useful for rendering associated templates.
 // When rendering layout templates use Layout as a convenience for setting the base template.
 //
-// If a template name does not exist in the map, it is executed using the root template.
+// If a template name has not been loaded, it is executed using the root template.
 // This is useful for rendering autoloaded templates.
-type Templates map[string]*template.Template
+type Templates struct {
+	templates  map[string]*template.Template
+	metadata   map[string]Metadata
+	lazy       map[string]*lazyTemplate
+	lazyRoot   *template.Template
+	locale     string
+	locales    *localized
+	contexts   map[string]*contextPool
+	ctx        context.Context
+	values     map[string]any
+	urls       *routeTable
+	components components
+}
 
 type templatesParser struct {
 	fsys           fs.FS
-	ext            string
+	exts           []string
 	layoutFilename string
+	extractors     map[string]Extractor
+	preprocessors  []Extractor
+	frontmatter    []string
 	templates      Templates
-	loadErr        error
+	loadErrs       []error
+	collectErrs    bool
+	strict         bool
+	checkURLs      bool
+	checkRoutes    bool
+	lazy           bool
+	skipFuncCheck  bool
 	onLoadFn       func(string, *template.Template)
+	onCollisionFn  func(*Collision) error
+	defined        map[string]string
+	bundle         *Bundle
+	walks          Bundle
+	components     components
+	i18n           *translations
+	routes         Routes
 }
 
 // New initializes a new templates parser from any fs.FS.
 func New(fsys fs.FS) *templatesParser {
 	root := template.New("<root>")
+	components := make(components)
+	urls := new(routeTable)
 	return &templatesParser{
 		fsys:           fsys,
-		ext:            "html",
+		exts:           []string{"html"},
 		layoutFilename: "layout",
+		extractors:     defaultExtractors(),
 		templates: Templates{
-			"<root>": root.Funcs(funcMap).Funcs(contextFuncMap(root)),
+			templates: map[string]*template.Template{
+				"<root>": root.Funcs(funcMap).Funcs(contextFuncMap(root, components)).Funcs(template.FuncMap{"url": urlFunc(urls)}),
+			},
+			metadata:   make(map[string]Metadata),
+			lazy:       make(map[string]*lazyTemplate),
+			urls:       urls,
+			components: components,
 		},
+		defined: make(map[string]string),
+		walks: Bundle{
+			Files:    make(map[string]BundleFile),
+			Autoload: make(map[string][]string),
+			Trees:    make(map[string]map[string][]string),
+			Catalogs: make(map[string]map[string]string),
+		},
+		components: components,
 	}
 }
 
@@ -108,22 +174,54 @@ func New(fsys fs.FS) *templatesParser {
 //
 // Clone returns an error if cloning any of the templates returns an error.
 func (t *templatesParser) Clone() (*templatesParser, error) {
-	templates := make(Templates, len(t.templates))
-	for k, v := range t.templates {
+	templates := Templates{
+		templates: make(map[string]*template.Template, len(t.templates.templates)),
+		metadata:  maps.Clone(t.templates.metadata),
+		lazy:      make(map[string]*lazyTemplate, len(t.templates.lazy)),
+		urls:      new(routeTable),
+	}
+	components := maps.Clone(t.components)
+	templates.components = components
+	for k, v := range t.templates.templates {
 		clone, err := v.Clone()
 		if err != nil {
 			return nil, err
 		}
-		templates[k] = clone.Funcs(contextFuncMap(clone))
+		templates.templates[k] = clone.Funcs(contextFuncMap(clone, components)).Funcs(template.FuncMap{"url": urlFunc(templates.urls)})
 	}
-	return &templatesParser{
+	tc := &templatesParser{
 		fsys:           t.fsys,
-		ext:            t.ext,
+		exts:           slices.Clone(t.exts),
 		layoutFilename: t.layoutFilename,
+		extractors:     maps.Clone(t.extractors),
+		preprocessors:  slices.Clone(t.preprocessors),
+		frontmatter:    slices.Clone(t.frontmatter),
 		templates:      templates,
-		loadErr:        t.loadErr,
+		loadErrs:       slices.Clone(t.loadErrs),
+		collectErrs:    t.collectErrs,
+		strict:         t.strict,
+		checkURLs:      t.checkURLs,
+		checkRoutes:    t.checkRoutes,
+		lazy:           t.lazy,
+		skipFuncCheck:  t.skipFuncCheck,
 		onLoadFn:       t.onLoadFn,
-	}, nil
+		onCollisionFn:  t.onCollisionFn,
+		defined:        maps.Clone(t.defined),
+		bundle:         t.bundle,
+		components:     components,
+		i18n:           t.i18n,
+		routes:         slices.Clone(t.routes),
+		walks: Bundle{
+			Files:    maps.Clone(t.walks.Files),
+			Autoload: maps.Clone(t.walks.Autoload),
+			Trees:    maps.Clone(t.walks.Trees),
+			Catalogs: maps.Clone(t.walks.Catalogs),
+		},
+	}
+	for k, v := range t.templates.lazy {
+		tc.templates.lazy[k] = &lazyTemplate{name: v.name, files: v.files}
+	}
+	return tc, nil
 }
 
 // MustClone clones a template parser with all it's loaded templates and configuration.
@@ -140,10 +238,15 @@ func (t *templatesParser) MustClone() *templatesParser {
 	return tc
 }
 
-// SetExt sets the file extension of template files.
+// SetExt sets the file extensions of template files.
 // Default is "html".
-func (t *templatesParser) SetExt(ext string) *templatesParser {
-	t.ext = ext
+//
+// Files with any of the extensions are loaded and the extension is stripped from the template name.
+// When files with the same name exist with more than one extension, the file with the first extension is loaded.
+//
+// ie. SetExt("html", "svg", "go") loads html pages, svg icons and single file go templates.
+func (t *templatesParser) SetExt(exts ...string) *templatesParser {
+	t.exts = exts
 	return t
 }
 
@@ -157,10 +260,122 @@ func (t *templatesParser) SetLayoutFilename(filename string) *templatesParser {
 	return t
 }
 
+// CollectErrors sets whether loading continues after a template fails to load.
+// Default is false.
+//
+// By default the first load error is returned by Parse and further calls to Autoload, Load and LoadTree are a noop.
+// When collect is true every template is loaded and Parse returns all load errors joined together.
+// Each load error is a *LoadError which reports the template name, file and line.
+func (t *templatesParser) CollectErrors(collect bool) *templatesParser {
+	t.collectErrs = collect
+	return t
+}
+
+// Strict sets whether loading a directory that contains no templates is an error.
+// Default is false.
+//
+// Directories that do not exist or cannot be walked are always reported as load errors.
+func (t *templatesParser) Strict(strict bool) *templatesParser {
+	t.strict = strict
+	return t
+}
+
+// CheckURLs sets whether Parse reports url calls with a constant route name that is not in the route table as load errors.
+// Default is false.
+//
+// Lazy templates are not checked, use Analyze to report them.
+func (t *templatesParser) CheckURLs(check bool) *templatesParser {
+	t.checkURLs = check
+	return t
+}
+
+// CheckRoutes sets whether LoadTree reports templates with a route that conflicts with the route of another template as load errors.
+// Default is false.
+//
+// Routes conflict if registering both patterns on http.ServeMux panics, ie. pages/about and pages/about/index.
+func (t *templatesParser) CheckRoutes(check bool) *templatesParser {
+	t.checkRoutes = check
+	return t
+}
+
+// Lazy sets whether Load and LoadTree only index templates and defer parsing them until first use.
+// Default is false.
+//
+// In lazy mode a template is parsed the first time it is rendered or looked up and cached afterwards,
+// concurrent first renders wait for a single parse. Lazy templates clone the root template as it is when Parse is called
+// and are parsed with the parser configuration at that time, later calls to SetExt, Overlay, OnLoad or Component do not apply to them.
+// Lazy templates are parsed one at a time so OnLoad and OnCollision callbacks are not called concurrently.
+// Errors from parsing a lazy template are returned when rendering the template instead of by Parse.
+//
+// Lazy can be used to reduce startup time and memory on sites with many pages.
+func (t *templatesParser) Lazy(lazy bool) *templatesParser {
+	t.lazy = lazy
+	return t
+}
+
+// SkipFuncCheck sets whether templates that call functions that are not defined can be parsed.
+// Default is false.
+//
+// Functions that are not defined are added as stubs that return an error when called.
+// This is useful for tools that only analyze or bundle templates without the functions added with Funcs,
+// like the graph and bundle commands of cmd/tmpl.
+func (t *templatesParser) SkipFuncCheck(skip bool) *templatesParser {
+	t.skipFuncCheck = skip
+	return t
+}
+
+// Overlay layers filesystems over the filesystem the parser was initialized with.
+//
+// Files in later layers shadow files with the same path in earlier layers, the filesystem passed to New is the first layer.
+// Directories are merged, so Autoload and LoadTree walk the files in all layers.
+// The layer each template file was loaded from is recorded in the template metadata.
+//
+// Overlay can be used to override embedded templates with templates from a directory on disk.
+func (t *templatesParser) Overlay(layers ...fs.FS) *templatesParser {
+	if o, ok := t.fsys.(overlayFS); ok {
+		t.fsys = append(slices.Clone(o), layers...)
+	} else {
+		t.fsys = append(overlayFS{t.fsys}, layers...)
+	}
+	return t
+}
+
+// SetExtractor sets the extractor for template files with the file extension ext.
+// The extractor turns the content of the file into template text before it is parsed.
+//
+// By default GoExtractor is set for the "go" extension, a nil extractor removes the extractor for ext.
+func (t *templatesParser) SetExtractor(ext string, e Extractor) *templatesParser {
+	if e == nil {
+		delete(t.extractors, ext)
+	} else {
+		t.extractors[ext] = e
+	}
+	return t
+}
+
+// Preprocess adds preprocessors for all template files.
+// Preprocessors are applied in order to the template text after it is extracted and before it is parsed.
+//
+// Preprocess can be used to minify or transform template text.
+func (t *templatesParser) Preprocess(preprocessors ...Extractor) *templatesParser {
+	t.preprocessors = append(t.preprocessors, preprocessors...)
+	return t
+}
+
+// Frontmatter sets the file extensions of template files that may start with frontmatter.
+// Default is none.
+//
+// The frontmatter is extracted after the extractor of the file extension and before preprocessors,
+// see the meta template func.
+func (t *templatesParser) Frontmatter(exts ...string) *templatesParser {
+	t.frontmatter = exts
+	return t
+}
+
 // Funcs adds the func maps to the template's func map.
 func (t *templatesParser) Funcs(funcMaps ...template.FuncMap) *templatesParser {
 	for _, f := range funcMaps {
-		t.templates["<root>"].Funcs(f)
+		t.templates.templates["<root>"].Funcs(f)
 	}
 	return t
 }
@@ -174,19 +389,57 @@ func (t *templatesParser) OnLoad(f func(name string, t *template.Template)) *tem
 	return t
 }
 
+// OnCollision sets f to be called when a template definition overrides an autoloaded template definition with the same name,
+// ie. when a page defines "components/button" or two autoloaded directories define the same template.
+// OnCollision applies to templates loaded after it is called.
+//
+// The Collision reports the template name and both source files.
+// If f returns nil the template is overridden and loading continues, so f can be used to log a warning.
+// If f returns an error the file is not parsed and the error is reported as a load error.
+// Empty definitions do not override existing templates and are not reported.
+func (t *templatesParser) OnCollision(f func(c *Collision) error) *templatesParser {
+	t.onCollisionFn = f
+	return t
+}
+
 // Autoload loads all templates in dirs to the root template.
 // The autoloaded templates are available in loaded templates as they clone the root template.
 //
+// Dirs that do not exist are reported as load errors.
+//
 // Autoload can be used to load common templates like components.
 func (t *templatesParser) Autoload(dirs ...string) *templatesParser {
-	if t.loadErr != nil {
+	if t.failed() {
 		return t
 	}
 	if len(dirs) == 0 {
 		return t
 	}
-	files := walkFiles(t.fsys, t.ext, dirs)
-	t.loadErr = parseFiles(t.fsys, t.templates["<root>"], t.ext, files)
+	var files []string
+	for _, dir := range dirs {
+		dirFiles, err := t.autoloadFiles(dir)
+		if err == nil && len(dirFiles) == 0 && t.strict {
+			err = newLoadError(dir, dir, ErrNoTemplates)
+		}
+		t.addErr(err)
+		files = append(files, dirFiles...)
+	}
+	if t.failed() {
+		return t
+	}
+	parsed, err := parseFiles(t.reader(), t.templates.templates["<root>"], files, func(file File, text string) error {
+		return t.checkCollisions(file, text, true)
+	})
+	t.addErr(err)
+	for _, file := range parsed {
+		t.templates.metadata[file.Name] = Metadata{
+			Name:       file.Name,
+			Files:      []File{file},
+			Ext:        file.Ext,
+			Autoloaded: true,
+			Meta:       file.Meta,
+		}
+	}
 	return t
 }
 
@@ -196,32 +449,47 @@ func (t *templatesParser) Autoload(dirs ...string) *templatesParser {
 // Template definitions in a file overrides template definitions in files to it's left
 // hence the returned template is named after the rightmost file.
 //
-// If an error occurs, it will be returned when calling Parse and further calls to Load will be a noop.
+// If an error occurs, it will be returned when calling Parse and further calls to Load will be a noop
+// unless errors are collected with CollectErrors.
 //
 // Templates file names are their filepath without the extension, this act as a namespace to avoid name collisions.
-// The file extension can be configured using SetExt, the default is "html".
+// The file extensions can be configured using SetExt, the default is "html".
 //
 // For instance, Load("a/foo", "b/foo") loads the template named "b/foo" and an associated template named "a/foo".
 func (t *templatesParser) Load(files ...string) *templatesParser {
-	if t.loadErr != nil {
+	if t.failed() {
 		return t
 	}
 	if len(files) == 0 {
 		return t
 	}
-	t.loadErr = t.load(files[len(files)-1], files)
+	t.recordFiles(files...)
+	t.addErr(t.load(files[len(files)-1], files))
 	return t
 }
 
 // LoadTree loads all templates in a directory including all layout templates.
+//
+// A directory that does not exist is reported as a load error.
+// With CheckRoutes templates with routes that conflict with the route of another template are reported as load errors.
 func (t *templatesParser) LoadTree(dir string) *templatesParser {
-	if t.loadErr != nil {
+	if t.failed() {
+		return t
+	}
+	groups, err := t.treeFiles(dir)
+	if err == nil && len(groups) == 0 && t.strict {
+		err = newLoadError(dir, dir, ErrNoTemplates)
+	}
+	t.addErr(err)
+	if t.checkRoutes {
+		t.addErr(t.routeErrors(dir, groups))
+	}
+	if t.failed() {
 		return t
 	}
-	groups := walkFilesWithLayout(t.fsys, t.ext, t.layoutFilename, dir)
 	for name, files := range groups {
-		if err := t.load(name, files); err != nil {
-			t.loadErr = err
+		t.addErr(t.load(name, files))
+		if t.failed() {
 			break
 		}
 	}
@@ -229,34 +497,170 @@ func (t *templatesParser) LoadTree(dir string) *templatesParser {
 }
 
 // load clones the root template and parses the named files into the new template.
+// In lazy mode load only indexes the files to be parsed on first use.
 func (t *templatesParser) load(name string, files []string) error {
 	if len(files) == 0 {
 		return nil
 	}
-	tmpl, err := t.templates["<root>"].Clone()
+	if t.lazy {
+		delete(t.templates.templates, name)
+		delete(t.templates.metadata, name)
+		t.templates.lazy[name] = &lazyTemplate{name: name, files: files}
+		return nil
+	}
+	tmpl, meta, err := t.parse(t.templates.templates["<root>"], name, files)
 	if err != nil {
 		return err
 	}
-	tmpl.Funcs(contextFuncMap(tmpl))
+	delete(t.templates.lazy, name)
+	t.templates.templates[name] = tmpl
+	t.templates.metadata[name] = meta
+	return nil
+}
+
+// parse clones root and parses the named files into the new template.
+func (t *templatesParser) parse(root *template.Template, name string, files []string) (*template.Template, Metadata, error) {
+	tmpl, err := root.Clone()
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	tmpl.Funcs(contextFuncMap(tmpl, t.components))
 	if t.onLoadFn != nil {
 		t.onLoadFn(name, tmpl)
 	}
-	err = parseFiles(t.fsys, tmpl, t.ext, files)
-	if err != nil {
-		return err
+	r := t.reader()
+	check := func(file File, text string) error {
+		return t.checkCollisions(file, text, false)
+	}
+	var parsed []File
+	var errs []error
+	specials := make(map[string]*template.Template)
+	for i, file := range files {
+		target := tmpl
+		if _, filename := filepath.Split(file); i < len(files)-1 && specialFilenames[filename] != "" {
+			// special files are parsed into their own clone so the templates they define are not added to the page
+			if target, err = tmpl.Clone(); err != nil {
+				return nil, Metadata{}, err
+			}
+			specials[file] = target
+		}
+		p, err := parseFiles(r, target, []string{file}, check)
+		parsed = append(parsed, p...)
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, Metadata{}, err
+	}
+	// name the template after the last file if it's name differs, ie. files in group directories
+	if last := files[len(files)-1]; last != name && tmpl.Lookup(last) != nil {
+		if _, err := tmpl.AddParseTree(name, tmpl.Lookup(last).Tree); err != nil {
+			return nil, Metadata{}, newLoadError(last, last, err)
+		}
+	}
+	// associate the top-level template of special files
+	for file, special := range specials {
+		_, filename := filepath.Split(file)
+		if special.Lookup(file) == nil {
+			continue
+		}
+		if _, err := tmpl.AddParseTree(specialFilenames[filename], special.Lookup(file).Tree); err != nil {
+			return nil, Metadata{}, newLoadError(file, file, err)
+		}
+	}
+	meta := Metadata{Name: name, Files: parsed}
+	for _, file := range parsed {
+		meta.Ext = file.Ext
+		if _, ok := specials[file.Name]; ok {
+			continue
+		}
+		meta.Meta = mergeMeta(meta.Meta, file.Meta)
+		if _, filename := filepath.Split(file.Name); filename == t.layoutFilename || filename == t.layoutFilename+"@" {
+			meta.Layouts = append(meta.Layouts, file.Name)
+		}
+	}
+	tmpl.Funcs(template.FuncMap{"meta": metaFunc(meta.Meta)})
+	return tmpl, meta, nil
+}
+
+// filePath returns the filepath of the template file name.
+func (t *templatesParser) filePath(name string) string {
+	if t.bundle != nil {
+		return t.bundle.Files[name].Path
+	}
+	path, _ := resolveFile(t.fsys, name, t.exts)
+	return path
+}
+
+// reader returns a reader for the parser's filesystem.
+func (t *templatesParser) reader() reader {
+	return reader{
+		fsys:          t.fsys,
+		exts:          t.exts,
+		extractors:    t.extractors,
+		preprocessors: t.preprocessors,
+		frontmatter:   t.frontmatter,
+		bundle:        t.bundle,
+		stubFuncs:     t.skipFuncCheck,
+	}
+}
+
+// failed reports whether a template failed to load and further loads should be skipped.
+func (t *templatesParser) failed() bool {
+	return len(t.loadErrs) > 0 && !t.collectErrs
+}
+
+// addErr records the errors joined in err.
+// Only the first error is kept unless errors are collected, repeated errors are kept once.
+func (t *templatesParser) addErr(err error) {
+	for _, err := range flattenErrors(err) {
+		if t.failed() {
+			return
+		}
+		if !slices.ContainsFunc(t.loadErrs, func(e error) bool { return e.Error() == err.Error() }) {
+			t.loadErrs = append(t.loadErrs, err)
+		}
 	}
-	t.templates[name] = tmpl
-	return nil
 }
 
 // Parse parses and returns Templates.
 //
 // Parse returns an error if loading any of the templates returned an error.
+// When errors are collected with CollectErrors, the returned error joins every load error.
+//
+// When I18n is used, Parse clones the templates for every locale and returns the templates of the fallback locale.
+//
+// Parse sets the route table of the url func, with CheckURLs url calls with a constant route name that is not in the route table return an error.
 func (t *templatesParser) Parse() (Templates, error) {
-	if t.loadErr != nil {
-		return nil, t.loadErr
+	routes := t.routeTable(t.templates)
+	t.templates.urls.routes.Store(&routes)
+	if t.checkURLs {
+		for _, err := range t.urlErrors(t.templates) {
+			t.addErr(err)
+		}
+	}
+	if len(t.loadErrs) == 1 {
+		return Templates{}, t.loadErrs[0]
+	}
+	if len(t.loadErrs) > 1 {
+		return Templates{}, errors.Join(t.loadErrs...)
+	}
+	if len(t.templates.lazy) > 0 {
+		// lazy templates clone a copy of the root template that is never executed
+		root, err := t.templates.templates["<root>"].Clone()
+		if err != nil {
+			return Templates{}, err
+		}
+		t.templates.lazyRoot = root
+		t.templates.lazy = t.lazyTemplates()
+	}
+	templates, err := t.withContexts(t.templates)
+	if err != nil {
+		return Templates{}, err
+	}
+	if t.i18n != nil {
+		return t.localize(templates)
 	}
-	return t.templates, nil
+	return templates, nil
 }
 
 // MustParse parses and returns Templates.
@@ -2,6 +2,11 @@ package tmpl
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"testing/fstest"
 )
@@ -44,6 +49,16 @@ func TestLoad(t *testing.T) {
 			template:  Tmpl("test", nil),
 			expected:  "<p>Test</p>",
 		},
+		{
+			templates: New(fs).SetExt("tmpl", "html").Load("test").MustParse(),
+			template:  Tmpl("test", nil),
+			expected:  "<p>Test tmpl</p>",
+		},
+		{
+			templates: New(fs).SetExt("go", "html").Load("test").MustParse(),
+			template:  Tmpl("test", nil),
+			expected:  "<p>Test html</p>",
+		},
 	}
 	for _, test := range tests {
 		err := test.templates.Render(buf, test.template)
@@ -270,3 +285,214 @@ func TestLoadTreeWithLayout(t *testing.T) {
 		buf.Reset()
 	}
 }
+
+func TestCollectErrors(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/ok.html": {
+			Data: []byte(`<p>Ok</p>`),
+		},
+		"components/broken.html": {
+			Data: []byte("<p>\n{{ if }}</p>"),
+		},
+		"pages/index.html": {
+			Data: []byte(`<p>{{ .Missing</p>`),
+		},
+		"pages/about.html": {
+			Data: []byte(`<p>About</p>`),
+		},
+	}
+
+	// stop at first error
+	_, err := New(fs).Autoload("components").LoadTree("pages").Load("missing").Parse()
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected LoadError got: %v", err)
+	}
+	if loadErr.Name != "components/broken" || loadErr.File != "components/broken.html" || loadErr.Line != 2 {
+		t.Errorf("unexpected load error: %+v", loadErr)
+	}
+	if len(flattenErrors(err)) != 1 {
+		t.Errorf("expected a single error got: %v", err)
+	}
+
+	// collect all errors
+	_, err = New(fs).CollectErrors(true).Autoload("components").LoadTree("pages").Load("missing").Parse()
+	expected := []string{"components/broken", "pages/index", "missing"}
+	var got []string
+	for _, err := range flattenErrors(err) {
+		if !errors.As(err, &loadErr) {
+			t.Fatalf("expected LoadError got: %v", err)
+		}
+		got = append(got, loadErr.Name)
+	}
+	if !slices.Equal(expected, got) {
+		t.Errorf("expected: %v, got: %v", expected, got)
+	}
+}
+
+type LazyPage struct {
+	Data AsyncValue[string, string]
+}
+
+func (l LazyPage) Tmpl() Template {
+	return Tmpl("sub/index", l)
+}
+
+func TestSpecialTemplates(t *testing.T) {
+	fs := fstest.MapFS{
+		"error.html": {
+			Data: []byte(`<p>Error: {{ . }}</p>`),
+		},
+		"not-found.html": {
+			Data: []byte(`<p>Not found</p>`),
+		},
+		"sub/loading.html": {
+			Data: []byte(`<p>Loading</p>`),
+		},
+		"sub/index.html": {
+			Data: []byte(`<div>{{ stream "data" .Data }}</div>{{ define "data" }}<p>{{ . }}</p>{{ end }}`),
+		},
+	}
+	templates := New(fs).LoadTree(".").MustParse()
+
+	buf := new(bytes.Buffer)
+	err := templates.Render(buf, Associated("sub/index", NotFoundTemplate, nil))
+	if err != nil {
+		t.Error(err)
+	}
+	if expected := "<p>Not found</p>"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+	buf.Reset()
+
+	// RenderError renders the nearest error template of the page
+	if err := templates.RenderError(buf, "sub/index", errors.New("failed")); err != nil {
+		t.Error(err)
+	}
+	if expected := "<p>Error: failed</p>"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+	buf.Reset()
+	renderErr := errors.New("failed")
+	if err := New(fs).Load("sub/index").MustParse().RenderError(buf, "sub/index", renderErr); err != renderErr || buf.Len() != 0 {
+		t.Errorf("expected render error without error template, got: %v", err)
+	}
+
+	// resolved stream error falls back to nearest error template
+	r := templates.SyncRenderer()
+	page := LazyPage{NewAsyncValue[string, string](r)}
+	page.Data.Err("failed")
+	if err := r.Render(buf, page); err != nil {
+		t.Error(err)
+	}
+	if expected := "<div><p>Error: failed</p></div>"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+	buf.Reset()
+
+	// pending stream falls back to nearest loading template
+	r = templates.StreamRenderer()
+	page = LazyPage{NewAsyncValue[string, string](r)}
+	// resolve the stream when the page with the pending content is flushed
+	var once sync.Once
+	w := flushWriter{buf, func() { once.Do(func() { page.Data.Ok("done") }) }}
+	if err := r.Render(w, page); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(buf.String(), "<p>Loading</p>") || !strings.Contains(buf.String(), "<p>done</p>") {
+		t.Errorf("expected loading and resolved content got: %q", buf.String())
+	}
+	buf.Reset()
+}
+
+// flushWriter is a writer that calls flush when it is flushed.
+type flushWriter struct {
+	io.Writer
+	flush func()
+}
+
+func (w flushWriter) Flush() { w.flush() }
+
+func TestSpecialTemplatesScope(t *testing.T) {
+	fs := fstest.MapFS{
+		"pages/layout.html":    {Data: []byte(`<title>{{ block "title" . }}Default{{ end }}</title>`)},
+		"pages/not-found.html": {Data: []byte(`{{ define "title" }}Not Found{{ end }}<p>Not found</p>`)},
+		"pages/index.html":     {Data: []byte(`<p>Index</p>`)},
+		"pages/about.html":     {Data: []byte(`{{ define "title" }}About{{ end }}<p>About</p>`)},
+	}
+	templates := New(fs).LoadTree("pages").MustParse()
+
+	tests := []struct {
+		tmpl     Template
+		expected string
+	}{
+		{Associated("pages/index", "pages/layout", nil), "<title>Default</title>"},
+		{Associated("pages/about", "pages/layout", nil), "<title>About</title>"},
+		{Associated("pages/not-found", "pages/layout", nil), "<title>Not Found</title>"},
+		{Associated("pages/index", NotFoundTemplate, nil), "<p>Not found</p>"},
+		{Associated("pages/about", NotFoundTemplate, nil), "<p>Not found</p>"},
+	}
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		if err := templates.Render(buf, test.tmpl); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("expected: %q, got: %q", test.expected, buf.String())
+		}
+	}
+	// only the special template alias is associated
+	if err := templates.Render(new(bytes.Buffer), Associated("pages/index", "pages/not-found", nil)); err == nil {
+		t.Errorf("expected special file template to not be associated")
+	}
+}
+
+func TestLoadTreeWithGroups(t *testing.T) {
+	fs := fstest.MapFS{
+		"layout.html": {
+			Data: []byte(`<h1>{{ .Data }}</h1>{{ slot .Children }}`),
+		},
+		"(auth)/layout@.html": {
+			Data: []byte(`<h2>{{ .Data }}</h2>{{ slot .Children }}`),
+		},
+		"(auth)/login.html": {
+			Data: []byte(`<p>{{ . }}</p>`),
+		},
+	}
+	templates := New(fs).LoadTree(".").MustParse()
+
+	buf := new(bytes.Buffer)
+	err := templates.Render(buf, Associated("login", "(auth)/layout@", Map{
+		"Data":     1,
+		"Children": Tmpl("login", 2),
+	}))
+	if err != nil {
+		t.Error(err)
+	}
+	if expected := "<h2>1</h2><p>2</p>"; buf.String() != expected {
+		t.Errorf("expected: %q, got: %q", expected, buf.String())
+	}
+	// root layout is not inherited
+	if err := templates.Render(buf, Associated("login", "layout", nil)); err == nil {
+		t.Errorf("expected root layout to be missing")
+	}
+}
+
+func TestSkipFuncCheck(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/asset.html": {Data: []byte(`{{ vite "main.ts" }}`)},
+		"pages/index.html":      {Data: []byte(`<p>{{ upper (vite "main.ts") }}</p>`)},
+	}
+	if _, err := New(fs).Autoload("components").LoadTree("pages").Parse(); err == nil {
+		t.Errorf("expected undefined function error")
+	}
+
+	templates, err := New(fs).SkipFuncCheck(true).Autoload("components").LoadTree("pages").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = templates.Render(new(bytes.Buffer), Tmpl("pages/index", nil))
+	if err == nil || !strings.Contains(err.Error(), `function "vite" is not defined`) {
+		t.Errorf("expected stub error, got: %v", err)
+	}
+}
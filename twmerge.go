@@ -0,0 +1,230 @@
+package tmpl
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// twMergeFunc composes classes like clsx and removes Tailwind CSS classes that conflict with later classes.
+//
+// Classes conflict when they set the same utility group with the same variants,
+// ie. "p-2 hover:bg-red-500 p-4 hover:bg-blue-500" merges to "p-4 hover:bg-blue-500".
+// Classes that are not recognized as Tailwind utilities are kept.
+func twMergeFunc(values ...any) (string, error) {
+	classes, err := clsxFunc(values...)
+	if err != nil {
+		return "", err
+	}
+	return twMerge(classes), nil
+}
+
+// twMerge removes classes that conflict with later classes.
+func twMerge(classes string) string {
+	fields := strings.Fields(classes)
+	seen := make(map[string]bool)
+	keep := make([]bool, len(fields))
+	for i := len(fields) - 1; i >= 0; i-- {
+		variants, utility := splitVariants(fields[i])
+		group := twGroup(utility)
+		if group == "" {
+			keep[i] = true
+			continue
+		}
+		key := variants + group
+		if seen[key] {
+			continue
+		}
+		keep[i] = true
+		seen[key] = true
+		for _, conflict := range twConflicts[group] {
+			seen[variants+conflict] = true
+		}
+	}
+	var merged []string
+	for i, class := range fields {
+		if keep[i] {
+			merged = append(merged, class)
+		}
+	}
+	return strings.Join(merged, " ")
+}
+
+// splitVariants returns the sorted variants prefix and the utility of a class,
+// the important modifier is part of the variants prefix.
+func splitVariants(class string) (string, string) {
+	var variants []string
+	depth, start := 0, 0
+	for i, r := range class {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				variants = append(variants, class[start:i])
+				start = i + 1
+			}
+		}
+	}
+	utility := class[start:]
+	if rest, ok := strings.CutPrefix(utility, "!"); ok {
+		variants, utility = append(variants, "!"), rest
+	} else if rest, ok := strings.CutSuffix(utility, "!"); ok {
+		variants, utility = append(variants, "!"), rest
+	}
+	if len(variants) == 0 {
+		return "", utility
+	}
+	slices.Sort(variants)
+	return strings.Join(variants, ":") + ":", utility
+}
+
+// twExact maps utilities without values to their group.
+var twExact = map[string]string{}
+
+func init() {
+	for group, utilities := range map[string][]string{
+		"display":         {"block", "inline-block", "inline", "flex", "inline-flex", "grid", "inline-grid", "hidden", "contents", "table", "table-row", "table-cell", "flow-root", "list-item"},
+		"position":        {"static", "fixed", "absolute", "relative", "sticky"},
+		"visibility":      {"visible", "invisible", "collapse"},
+		"flex-direction":  {"flex-row", "flex-row-reverse", "flex-col", "flex-col-reverse"},
+		"flex-wrap":       {"flex-wrap", "flex-wrap-reverse", "flex-nowrap"},
+		"text-align":      {"text-left", "text-center", "text-right", "text-justify", "text-start", "text-end"},
+		"text-decoration": {"underline", "overline", "line-through", "no-underline"},
+		"text-transform":  {"uppercase", "lowercase", "capitalize", "normal-case"},
+		"font-style":      {"italic", "not-italic"},
+		"text-overflow":   {"truncate", "text-ellipsis", "text-clip"},
+		"border-style":    {"border-solid", "border-dashed", "border-dotted", "border-double", "border-hidden", "border-none"},
+		"bg-size":         {"bg-auto", "bg-cover", "bg-contain"},
+		"bg-repeat":       {"bg-repeat", "bg-no-repeat", "bg-repeat-x", "bg-repeat-y", "bg-repeat-round", "bg-repeat-space"},
+		"bg-attachment":   {"bg-fixed", "bg-local", "bg-scroll"},
+		"bg-position":     {"bg-bottom", "bg-center", "bg-left", "bg-left-bottom", "bg-left-top", "bg-right", "bg-right-bottom", "bg-right-top", "bg-top"},
+		"shadow":          {"shadow"},
+		"rounded":         {"rounded"},
+		"border-w":        {"border"},
+		"ring-w":          {"ring"},
+		"transition":      {"transition"},
+		"grow":            {"grow"},
+		"shrink":          {"shrink"},
+	} {
+		for _, utility := range utilities {
+			twExact[utility] = group
+		}
+	}
+}
+
+// twPrefixes are utility prefixes whose values always belong to one group, longer prefixes are matched first.
+var twPrefixes = []string{
+	"min-w", "min-h", "max-w", "max-h", "size", "w", "h",
+	"px", "py", "ps", "pe", "pt", "pr", "pb", "pl", "p",
+	"mx", "my", "ms", "me", "mt", "mr", "mb", "ml", "m",
+	"inset-x", "inset-y", "inset", "top", "right", "bottom", "left", "start", "end",
+	"gap-x", "gap-y", "gap", "space-x", "space-y",
+	"rounded-tl", "rounded-tr", "rounded-br", "rounded-bl", "rounded-t", "rounded-r", "rounded-b", "rounded-l", "rounded-s", "rounded-e", "rounded",
+	"overflow-x", "overflow-y", "overflow",
+	"items", "justify-items", "justify-self", "justify", "content", "self", "place-items", "place-content", "place-self",
+	"grid-cols", "grid-rows", "col-span", "col-start", "col-end", "row-span", "row-start", "row-end", "order", "basis", "flex", "grow", "shrink",
+	"z", "opacity", "leading", "tracking", "whitespace", "break", "cursor", "select", "pointer-events",
+	"duration", "delay", "ease", "transition", "animate", "aspect", "columns",
+	"translate-x", "translate-y", "rotate", "scale-x", "scale-y", "scale", "skew-x", "skew-y", "origin",
+	"line-clamp", "underline-offset", "indent", "align", "blur", "brightness", "contrast", "grayscale", "backdrop-blur",
+}
+
+// twColorPrefixes are utility prefixes whose values are either a color or another group.
+var twColorPrefixes = []string{"text", "bg", "border-x", "border-y", "border-t", "border-r", "border-b", "border-l", "border", "ring", "outline", "shadow", "divide", "fill", "stroke", "from", "via", "to", "accent", "caret", "placeholder"}
+
+var (
+	twColor      = regexp.MustCompile(`^(inherit|current|transparent|black|white|(slate|gray|zinc|neutral|stone|red|orange|amber|yellow|lime|green|emerald|teal|cyan|sky|blue|indigo|violet|purple|fuchsia|pink|rose)-\d+|\[(#|rgb|hsl|color:).*\])(/.+)?$`)
+	twWidth      = regexp.MustCompile(`^(\d+|px|\[\d.*(px|rem|em)\])$`)
+	twTextSize   = regexp.MustCompile(`^(xs|sm|base|lg|\d?xl|\[\d.*\])(/.+)?$`)
+	twFontWeight = regexp.MustCompile(`^(thin|extralight|light|normal|medium|semibold|bold|extrabold|black|\[\d+\])$`)
+	twShadowSize = regexp.MustCompile(`^(sm|md|lg|xl|2xl|inner|none)$`)
+)
+
+// twGroup returns the conflict group of a Tailwind utility or an empty string if it is not recognized.
+func twGroup(utility string) string {
+	utility = strings.TrimPrefix(utility, "-")
+	if group, ok := twExact[utility]; ok {
+		return group
+	}
+	if value, ok := strings.CutPrefix(utility, "font-"); ok {
+		if twFontWeight.MatchString(value) {
+			return "font-weight"
+		}
+		return "font-family"
+	}
+	for _, prefix := range twColorPrefixes {
+		value, ok := strings.CutPrefix(utility, prefix+"-")
+		if !ok {
+			continue
+		}
+		if twColor.MatchString(value) {
+			return prefix + "-color"
+		}
+		switch prefix {
+		case "text":
+			if twTextSize.MatchString(value) {
+				return "font-size"
+			}
+			return ""
+		case "bg":
+			if value == "none" || strings.HasPrefix(value, "gradient-") || strings.HasPrefix(value, "[url(") {
+				return "bg-image"
+			}
+			return ""
+		case "border", "border-x", "border-y", "border-t", "border-r", "border-b", "border-l":
+			if twWidth.MatchString(value) {
+				return strings.Replace(prefix, "border", "border-w", 1)
+			}
+			return ""
+		case "ring", "outline", "divide":
+			if twWidth.MatchString(value) {
+				return prefix + "-w"
+			}
+			return ""
+		case "shadow":
+			if twShadowSize.MatchString(value) {
+				return "shadow"
+			}
+			return ""
+		}
+		return ""
+	}
+	for _, prefix := range twPrefixes {
+		if strings.HasPrefix(utility, prefix+"-") {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// twConflicts maps groups to the groups they override.
+var twConflicts = map[string][]string{
+	"p":              {"px", "py", "ps", "pe", "pt", "pr", "pb", "pl"},
+	"px":             {"pr", "pl", "ps", "pe"},
+	"py":             {"pt", "pb"},
+	"m":              {"mx", "my", "ms", "me", "mt", "mr", "mb", "ml"},
+	"mx":             {"mr", "ml", "ms", "me"},
+	"my":             {"mt", "mb"},
+	"size":           {"w", "h"},
+	"inset":          {"inset-x", "inset-y", "top", "right", "bottom", "left", "start", "end"},
+	"inset-x":        {"right", "left"},
+	"inset-y":        {"top", "bottom"},
+	"gap":            {"gap-x", "gap-y"},
+	"overflow":       {"overflow-x", "overflow-y"},
+	"rounded":        {"rounded-t", "rounded-r", "rounded-b", "rounded-l", "rounded-s", "rounded-e", "rounded-tl", "rounded-tr", "rounded-br", "rounded-bl"},
+	"rounded-t":      {"rounded-tl", "rounded-tr"},
+	"rounded-r":      {"rounded-tr", "rounded-br"},
+	"rounded-b":      {"rounded-br", "rounded-bl"},
+	"rounded-l":      {"rounded-tl", "rounded-bl"},
+	"border-w":       {"border-w-x", "border-w-y", "border-w-t", "border-w-r", "border-w-b", "border-w-l"},
+	"border-w-x":     {"border-w-r", "border-w-l"},
+	"border-w-y":     {"border-w-t", "border-w-b"},
+	"border-color":   {"border-x-color", "border-y-color", "border-t-color", "border-r-color", "border-b-color", "border-l-color"},
+	"border-x-color": {"border-r-color", "border-l-color"},
+	"border-y-color": {"border-t-color", "border-b-color"},
+	"flex":           {"grow", "shrink", "basis"},
+	"scale":          {"scale-x", "scale-y"},
+}
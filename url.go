@@ -0,0 +1,178 @@
+package tmpl
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync/atomic"
+	"text/template/parse"
+)
+
+// routeTable is the route table used by the url func, it is set when templates are parsed.
+type routeTable struct {
+	routes atomic.Pointer[Routes]
+}
+
+// lookup returns the route of the template name or the index template in the directory name.
+func (rt *routeTable) lookup(name string) (Route, bool) {
+	if rt == nil || rt.routes.Load() == nil {
+		return Route{}, false
+	}
+	routes := *rt.routes.Load()
+	if route, ok := routes.Lookup(name); ok {
+		return route, true
+	}
+	return routes.Lookup(path.Join(name, "index"))
+}
+
+// urlFunc returns the url func which returns the URL of a route in the route table.
+//
+//	url name [params]   params are key value pairs or a map of dynamic segments and query parameters
+func urlFunc(rt *routeTable) any {
+	return func(name string, params ...any) (string, error) {
+		route, ok := rt.lookup(name)
+		if !ok {
+			return "", fmt.Errorf("url %q: route not found", name)
+		}
+		u, err := route.URL(params...)
+		if err != nil {
+			return "", fmt.Errorf("url %q: %w", name, err)
+		}
+		return u, nil
+	}
+}
+
+// URL returns the URL path of the route with the dynamic segments set to params.
+//
+// Params are key value pairs or a map, params that are not dynamic segments are added as query parameters.
+// Param values are path escaped, catch-all values are escaped for each segment.
+func (r Route) URL(params ...any) (string, error) {
+	values, err := keyValues(params)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	segments := patternSegments(r.Pattern)
+	if len(segments) == 0 {
+		sb.WriteByte('/')
+	}
+	for _, segment := range segments {
+		sb.WriteByte('/')
+		if segment == "{$}" {
+			continue
+		}
+		rank := segmentRank(segment)
+		if rank == 0 {
+			sb.WriteString(segment)
+			continue
+		}
+		param := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+		v, ok := values[param]
+		if !ok || v == nil {
+			return "", fmt.Errorf("missing param %s", param)
+		}
+		delete(values, param)
+		s := fmt.Sprint(v)
+		if rank == 1 {
+			if s == "" {
+				return "", fmt.Errorf("param %s must not be empty", param)
+			}
+			sb.WriteString(url.PathEscape(s))
+			continue
+		}
+		parts := strings.Split(s, "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+		sb.WriteString(strings.Join(parts, "/"))
+	}
+	query := make(url.Values)
+	for k, v := range values {
+		if v != nil {
+			query.Set(k, fmt.Sprint(v))
+		}
+	}
+	if len(query) > 0 {
+		sb.WriteString("?" + query.Encode())
+	}
+	return sb.String(), nil
+}
+
+// URL returns the URL of the route of the template name, see Route.URL.
+// The route table contains the routes of the directories loaded with LoadTree and the routes registered with Route.
+func (t Templates) URL(name string, params ...any) (string, error) {
+	return urlFunc(t.urls).(func(string, ...any) (string, error))(name, params...)
+}
+
+// Route registers the URL pattern of a handler that does not render a template loaded with LoadTree,
+// so the url func can return it's URL, ie. Route("logout", "POST /auth/logout").
+// The pattern uses http.ServeMux syntax and the method is ignored.
+func (t *templatesParser) Route(name, pattern string) *templatesParser {
+	if _, p, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimSpace(p)
+	}
+	route := Route{Pattern: pattern, Name: name}
+	for _, segment := range patternSegments(pattern) {
+		if segmentRank(segment) > 0 && segment != "{$}" {
+			route.Params = append(route.Params, strings.TrimSuffix(strings.Trim(segment, "{}"), "..."))
+		}
+	}
+	t.routes = append(t.routes, route)
+	return t
+}
+
+// routeTable returns the routes of the directories loaded with LoadTree and the routes registered with Route.
+func (t *templatesParser) routeTable(templates Templates) Routes {
+	var routes Routes
+	for dir := range t.walks.Trees {
+		routes = append(routes, templates.Routes(dir)...)
+	}
+	return append(routes, t.routes...)
+}
+
+// urlErrors returns load errors for url calls with a constant route name that is not in the route table.
+// Lazy templates are not checked.
+func (t *templatesParser) urlErrors(templates Templates) []error {
+	paths := make(map[string]string)
+	for _, meta := range templates.metadata {
+		for _, file := range meta.Files {
+			paths[file.Name] = file.Path
+		}
+	}
+	var errs []error
+	for _, tmpl := range templates.templates {
+		for _, assoc := range tmpl.Templates() {
+			if assoc.Tree == nil || assoc.Tree.Root == nil {
+				continue
+			}
+			for _, ref := range urlReferences(assoc.Name(), assoc.Tree) {
+				if _, ok := templates.urls.lookup(ref.Name); ok {
+					continue
+				}
+				file := assoc.Tree.ParseName
+				errs = append(errs, newLoadError(file, paths[file], fmt.Errorf("template: %s: url %q: route not found", ref.Location, ref.Name)))
+			}
+		}
+	}
+	return errs
+}
+
+// urlReferences returns the url calls with a constant route name in the parse tree of the template name.
+func urlReferences(name string, tree *parse.Tree) []Reference {
+	var refs []Reference
+	walkTree(tree.Root, func(node parse.Node) {
+		cmd, ok := node.(*parse.CommandNode)
+		if !ok || len(cmd.Args) < 2 {
+			return
+		}
+		if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "url" {
+			return
+		}
+		if str, ok := cmd.Args[1].(*parse.StringNode); ok {
+			location, _ := tree.ErrorContext(cmd)
+			refs = append(refs, Reference{Name: str.Text, Kind: "url", From: name, Location: location})
+		}
+	})
+	return refs
+}
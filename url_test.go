@@ -0,0 +1,133 @@
+package tmpl
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestURLFunc(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/link.html":              {Data: []byte(`<a href="{{ url "pages/users/[id]" "id" .ID }}">{{ url "pages" }}</a>`)},
+		"pages/index.html":                  {Data: []byte(`{{ url "pages/docs/[...slug]" "slug" .Slug "q" .Query }}|{{ url "logout" }}|{{ url "pages/users/[id]/posts/[pid]" (map "id" .ID "pid" .PID) }}`)},
+		"pages/users/[id]/index.html":       {},
+		"pages/users/[id]/posts/[pid].html": {},
+		"pages/docs/[...slug]/index.html":   {},
+	}
+	templates := New(fs).Autoload("components").LoadTree("pages").Route("logout", "POST /auth/logout").MustParse()
+
+	tests := []struct {
+		tmpl     Template
+		expected string
+	}{
+		{Tmpl("components/link", map[string]any{"ID": 42}), `<a href="/users/42">/</a>`},
+		{Tmpl("components/link", map[string]any{"ID": "a b/c"}), `<a href="/users/a%20b%2Fc">/</a>`},
+		{
+			Tmpl("pages/index", map[string]any{"Slug": "guide/a b", "Query": "x&y", "ID": 1, "PID": "p"}),
+			`/docs/guide/a%20b?q=x%26y|/auth/logout|/users/1/posts/p`,
+		},
+	}
+	for _, test := range tests {
+		var sb strings.Builder
+		if err := templates.Render(&sb, test.tmpl); err != nil {
+			t.Fatal(err)
+		}
+		if sb.String() != test.expected {
+			t.Errorf("expected: %q, got: %q", test.expected, sb.String())
+		}
+	}
+
+	if u, err := templates.URL("pages/users/[id]", "id", 7, "tab", "posts"); err != nil || u != "/users/7?tab=posts" {
+		t.Errorf("expected: %q, got: %q, %v", "/users/7?tab=posts", u, err)
+	}
+	if _, err := templates.URL("pages/missing"); err == nil || err.Error() != `url "pages/missing": route not found` {
+		t.Errorf("expected route not found error, got: %v", err)
+	}
+
+	// cloned parsers have their own route table
+	clone, err := New(fs).LoadTree("pages/users").Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloned := clone.Route("home", "/").MustParse()
+	if u, err := cloned.URL("home"); err != nil || u != "/" {
+		t.Errorf("expected: %q, got: %q, %v", "/", u, err)
+	}
+	if _, err := templates.URL("home"); err == nil {
+		t.Errorf("expected route of clone not to be in the original route table")
+	}
+}
+
+func TestRouteURL(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		params   []any
+		expected string
+		err      string
+	}{
+		{pattern: "/{$}", expected: "/"},
+		{pattern: "/posts/{$}", expected: "/posts/"},
+		{pattern: "/users/{id}", params: []any{"id", "é?#"}, expected: "/users/%C3%A9%3F%23"},
+		{pattern: "/users/{id}", params: []any{map[string]any{"id": 1, "page": 2, "sort": "a b"}}, expected: "/users/1?page=2&sort=a+b"},
+		{pattern: "/docs/{slug...}", params: []any{"slug", "a/b c/../d"}, expected: "/docs/a/b%20c/../d"},
+		{pattern: "/docs/{slug...}", params: []any{"slug", ""}, expected: "/docs/"},
+		{pattern: "/users/{id}", params: []any{"id", nil, "q", nil}, err: "missing param id"},
+		{pattern: "/users/{id}", err: "missing param id"},
+		{pattern: "/users/{id}", params: []any{"id", ""}, err: "param id must not be empty"},
+		{pattern: "/users/{id}", params: []any{"id"}, err: "key id missing value"},
+	}
+	for _, test := range tests {
+		u, err := Route{Pattern: test.pattern}.URL(test.params...)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected err: %q, got: %v", test.pattern, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected err: %v", test.pattern, err)
+			continue
+		}
+		if u != test.expected {
+			t.Errorf("%s: expected: %q, got: %q", test.pattern, test.expected, u)
+		}
+	}
+}
+
+func TestURLCheck(t *testing.T) {
+	fs := fstest.MapFS{
+		"components/nav.html":    {Data: []byte(`<a href="{{ url "pages/about" }}"></a>`)},
+		"pages/index.html":       {Data: []byte("{{ template \"components/nav\" }}\n{{ url \"pages/missing\" }}{{ url .Name }}")},
+		"pages/about/index.html": {},
+	}
+
+	// without CheckURLs unknown routes are reported by Analyze
+	analysis := New(fs).Autoload("components").LoadTree("pages").MustParse().Analyze()
+	expected := []Reference{
+		{Name: "pages/missing", Kind: "url", From: "pages/index", Location: "pages/index:2:3"},
+	}
+	if !slices.Equal(expected, analysis.Missing) {
+		t.Errorf("expected: %v, got: %v", expected, analysis.Missing)
+	}
+
+	_, err := New(fs).CheckURLs(true).Autoload("components").LoadTree("pages").Parse()
+	var le *LoadError
+	if !errors.As(err, &le) {
+		t.Fatalf("expected load error, got: %v", err)
+	}
+	if le.File != "pages/index.html" || le.Line != 2 || !strings.Contains(le.Error(), `url "pages/missing": route not found`) {
+		t.Errorf("expected url error in pages/index.html at line 2, got: %v", le)
+	}
+
+	// strict mode does not check urls
+	if _, err := New(fs).Strict(true).Autoload("components").LoadTree("pages").Parse(); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	// routes registered with Route are not reported
+	if _, err := New(fs).CheckURLs(true).Autoload("components").LoadTree("pages").Route("pages/missing", "/missing").Parse(); err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+}